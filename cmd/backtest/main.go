@@ -0,0 +1,252 @@
+// Command backtest replays recorded order book snapshots through the
+// detectors and reports what each MinDiff/MaxSum combination would have signalled.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/usecase"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/cache"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/recorder"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+)
+
+type params struct {
+	minDiff float64
+	maxSum  float64
+}
+
+type result struct {
+	params
+	ticks     int
+	counts    map[domain.SignalType]int
+	signals   int
+	marginSum float64
+	marginMax float64
+	pnl       float64
+	// open — ключи depth/triangle сигналов прошлого тика: PnL считается один
+	// раз на возможность, когда она открылась, а не на каждом тике
+	open map[string]bool
+}
+
+func (r *result) add(typ domain.SignalType, margin float64) {
+	r.counts[typ]++
+	r.signals++
+	r.marginSum += margin
+	r.marginMax = math.Max(r.marginMax, margin)
+}
+
+// addPnL counts profit for key unless the opportunity was already open on the
+// previous tick.
+func (r *result) addPnL(key string, profit float64, seen map[string]bool) {
+	if !r.open[key] && !seen[key] {
+		r.pnl += profit
+	}
+	seen[key] = true
+}
+
+func main() {
+	var (
+		file     = flag.String("file", "snapshots.jsonl", "snapshot file written by the recorder")
+		mins     = flag.String("min", "0.1", "comma-separated MinDiff values")
+		maxs     = flag.String("max", "1000", "comma-separated MaxSum values")
+		feesPath = flag.String("fees", "", "fee schedule JSON (defaults if empty)")
+		step     = flag.Duration("step", 20*time.Second, "replay tick interval")
+		ttl      = flag.Duration("ttl", 60*time.Second, "ignore books older than this at a tick")
+	)
+	flag.Parse()
+
+	logger.InitLog("fatal")
+
+	if *feesPath != "" {
+		fees, err := usecase.LoadFeeSchedule(*feesPath)
+		if err != nil {
+			fail("failed to load fee schedule: %v", err)
+		}
+		usecase.SetFeeSchedule(fees)
+	}
+
+	minList, err := parseFloats(*mins)
+	if err != nil {
+		fail("invalid -min: %v", err)
+	}
+	maxList, err := parseFloats(*maxs)
+	if err != nil {
+		fail("invalid -max: %v", err)
+	}
+	if *step <= 0 {
+		fail("-step must be positive")
+	}
+
+	var snaps []*recorder.Snapshot
+	if err := recorder.ReadSnapshots(*file, func(s *recorder.Snapshot) error {
+		snaps = append(snaps, s)
+		return nil
+	}); err != nil {
+		fail("failed to read snapshots: %v", err)
+	}
+	if len(snaps) == 0 {
+		fail("no snapshots in %s", *file)
+	}
+	sort.SliceStable(snaps, func(i, j int) bool { return snaps[i].At.Before(snaps[j].At) })
+
+	var results []*result
+	for _, mn := range minList {
+		for _, mx := range maxList {
+			results = append(results, &result{
+				params: params{minDiff: mn, maxSum: mx},
+				counts: make(map[domain.SignalType]int),
+			})
+		}
+	}
+
+	state := make(map[cache.OrderCacheKey]*recorder.Snapshot)
+	next := snaps[0].At.Add(*step)
+	for _, s := range snaps {
+		for !s.At.Before(next) {
+			runTick(booksAt(state, next, *ttl), results)
+			next = next.Add(*step)
+		}
+		state[s.Key()] = s
+	}
+	runTick(booksAt(state, next, *ttl), results)
+
+	fmt.Printf("Snapshots: %d, from %s to %s, step %s\n\n",
+		len(snaps), snaps[0].At.Format(time.RFC3339), snaps[len(snaps)-1].At.Format(time.RFC3339), *step)
+	report(results)
+}
+
+// booksAt assembles the books that were fresh at time t.
+func booksAt(state map[cache.OrderCacheKey]*recorder.Snapshot, t time.Time, ttl time.Duration) []*domain.OrderBook {
+	type bookKey struct {
+		source domain.Source
+		pair   domain.Pair
+	}
+	byBook := make(map[bookKey]*domain.OrderBook)
+	var order []bookKey
+
+	for key, s := range state {
+		if t.Sub(s.At) > ttl {
+			continue
+		}
+		bk := bookKey{key.Source, key.Pair}
+		b, ok := byBook[bk]
+		if !ok {
+			b = &domain.OrderBook{Source: key.Source, Pair: key.Pair}
+			byBook[bk] = b
+			order = append(order, bk)
+		}
+		switch key.Side {
+		case domain.SideBuy:
+			b.Asks = s.Orders()
+		case domain.SideSell:
+			b.Bids = s.Orders()
+		}
+	}
+
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].source != order[j].source {
+			return order[i].source < order[j].source
+		}
+		return order[i].pair < order[j].pair
+	})
+	books := make([]*domain.OrderBook, 0, len(order))
+	for _, bk := range order {
+		if b := byBook[bk]; len(b.Asks) > 0 && len(b.Bids) > 0 {
+			books = append(books, b)
+		}
+	}
+	return books
+}
+
+func runTick(books []*domain.OrderBook, results []*result) {
+	if len(books) == 0 {
+		return
+	}
+	for _, r := range results {
+		r.ticks++
+		seen := make(map[string]bool)
+
+		facts, _ := usecase.DetectFactFromBooks(books, r.minDiff, r.maxSum)
+		for _, op := range facts {
+			r.add(domain.SignalFact, op.ProfitMargin)
+		}
+
+		ops, pots, _ := usecase.DetectASFromBooks(books, r.minDiff, r.maxSum)
+		for _, op := range ops {
+			r.add(domain.SignalPotential, op.ProfitMargin)
+		}
+		for _, op := range pots {
+			r.add(domain.SignalReverse, op.ProfitMargin)
+		}
+
+		depth, _ := usecase.DetectDepthFromBooks(books, r.minDiff, r.maxSum)
+		for _, op := range depth {
+			r.add(domain.SignalDepth, op.ProfitMargin)
+			r.addPnL(usecase.SignalKey(domain.SignalDepth, op), op.TotalProfit, seen)
+		}
+
+		triangles, _ := usecase.DetectTriangleFromBooks(books, r.minDiff, r.maxSum)
+		for _, op := range triangles {
+			margin := 0.0
+			if op.Volume > 0 {
+				margin = op.TotalProfit / op.Volume
+			}
+			r.add(domain.SignalTriangle, margin)
+			r.addPnL(usecase.TriangleKey(op), op.TotalProfit, seen)
+		}
+		r.open = seen
+	}
+}
+
+func report(results []*result) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "MinDiff\tMaxSum\tTicks\tFact\tPotential\tReverse\tDepth\tTriangle\tAvg margin\tMax margin\tPnL RUB\t")
+	for _, r := range results {
+		avg := 0.0
+		if r.signals > 0 {
+			avg = r.marginSum / float64(r.signals)
+		}
+		fmt.Fprintf(tw, "%.2f\t%.2f\t%d\t%d\t%d\t%d\t%d\t%d\t%.4f\t%.4f\t%.2f\t\n",
+			r.minDiff, r.maxSum, r.ticks,
+			r.counts[domain.SignalFact], r.counts[domain.SignalPotential], r.counts[domain.SignalReverse],
+			r.counts[domain.SignalDepth], r.counts[domain.SignalTriangle],
+			avg, r.marginMax, r.pnl)
+	}
+	tw.Flush()
+	fmt.Println("\nPnL counts depth and triangle signals only, the ones with an executable volume,")
+	fmt.Println("once per opportunity: at the tick it opened, not on every tick it stayed open.")
+}
+
+func parseFloats(s string) ([]float64, error) {
+	var out []float64
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no values")
+	}
+	return out, nil
+}
+
+func fail(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
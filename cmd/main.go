@@ -1,11 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // часовые пояса пользователей без zoneinfo в образе
 
+	"github.com/Shyyw1e/arbitrage-sync/internal/config"
 	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/cache"
 	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/db"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/health"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/marketdata"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/metrics"
 	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/parser"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/recorder"
 	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/redisqueue"
 	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/telegram"
 	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
@@ -15,39 +27,150 @@ import (
 
 var GlobalOrderCache *cache.OrderCache
 
+// shutdownTimeout bounds how long workers get to finish their tick on exit.
+const shutdownTimeout = 30 * time.Second
+
 func main() {
-	logger.InitLog("debug")
+	logger.InitLog("info")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	if err := godotenv.Load(); err != nil {
 		logger.Log.Errorf("failed to load .env: %v", err)
 	}
 
+	cfg, err := config.Load(config.Path())
+	if err != nil {
+		logger.Log.Fatalf("%v", err)
+	}
+	logger.InitLog(cfg.LogLevel)
+
+	parser.SetChromeTimeout(cfg.Parser.ChromeTimeout)
+	parser.SetBookDepth(cfg.Parser.Depth)
 	if err := parser.StartChromeAllocator(); err != nil {
 		logger.Log.Fatalf("chrome allocator start: %v", err)
 	}
 	defer parser.StopChromeAllocator()
-	parser.SetChromeParallelLimit(1)
+	parser.SetChromeParallelLimit(cfg.Parser.ParallelLimit)
+	parser.SetGrinexAPIURL(cfg.Parser.GrinexAPIURL)
+	applySources(cfg)
+
+	cache.GlobalOrderCache.SetTTL(cfg.Cache.TTL)
+	if cfg.SnapshotFile != "" {
+		rec, err := recorder.NewFileRecorder(cfg.SnapshotFile)
+		if err != nil {
+			logger.Log.Fatalf("failed to start snapshot recorder: %v", err)
+		}
+		defer rec.Close()
+		rec.Attach(cache.GlobalOrderCache)
+		logger.Log.Infof("Recording order book snapshots to %s", cfg.SnapshotFile)
+	}
 
-	if err := redisqueue.InitRedisClient(); err != nil {
+	marketdata.DefaultHub.Attach(cache.GlobalOrderCache)
+	go marketdata.NewPoller(parser.DefaultRegistry, cache.GlobalOrderCache, cfg.Parser.PollInterval).Run(ctx)
+
+	if err := loadFees(cfg.FeesFile); err != nil {
+		logger.Log.Fatalf("failed to load fee schedule: %v", err)
+	}
+
+	if err := redisqueue.InitRedisClient(cfg.Redis); err != nil {
 		logger.Log.Fatalf("failed to init redis: %v", err)
 	}
+	if err := redisqueue.SetQueueBackend(cfg.Queue.Backend); err != nil {
+		logger.Log.Fatalf("invalid job queue backend: %v", err)
+	}
+	if err := redisqueue.SetQueueMode(cfg.Queue.Mode); err != nil {
+		logger.Log.Fatalf("invalid job queue mode: %v", err)
+	}
+	redisqueue.ConfigureWorkers(cfg.Worker)
+	redisqueue.SetDedup(cfg.Dedup)
 
-	store, err := db.NewSQLiteUserStateStore("data.db")
+	store, err := db.NewSQLiteUserStateStore(cfg.DBPath)
 	if err != nil {
 		logger.Log.Fatalf("failed to initialize SQLite store: %v", err)
 	}
 
-	botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
-	if botToken == "" {
-		logger.Log.Fatalf("TELEGRAM_BOT_TOKEN is empty")
+	journal, err := db.NewSQLiteOpportunityStore(cfg.DBPath)
+	if err != nil {
+		logger.Log.Fatalf("failed to initialize opportunity journal: %v", err)
 	}
-	bot, err := tgbotapi.NewBotAPI(botToken)
+
+	telegram.Configure(cfg.Telegram)
+	bot, err := tgbotapi.NewBotAPI(cfg.Telegram.Token)
 	if err != nil {
 		logger.Log.Fatalf("Telegram bot init error: %v", err)
 	}
 
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/healthz", health.Default.HealthzHandler())
+	mux.Handle("/readyz", health.Default.ReadyzHandler())
+
+	health.Default.Register("chrome", health.Liveness, func(ctx context.Context) (any, error) {
+		return nil, parser.EnsureAlive()
+	})
+	health.Default.Register("workers", health.Liveness, func(ctx context.Context) (any, error) {
+		return redisqueue.CheckWorkers()
+	})
+	health.Default.Register("redis", health.Readiness, func(ctx context.Context) (any, error) {
+		return nil, redisqueue.Ping(ctx)
+	})
+	health.Default.Register("sqlite", health.Readiness, func(ctx context.Context) (any, error) {
+		if err := store.Ping(ctx); err != nil {
+			return nil, err
+		}
+		return nil, journal.Ping(ctx)
+	})
+	health.Default.Register("telegram", health.Readiness, func(ctx context.Context) (any, error) {
+		return nil, telegram.CheckHealth(ctx, bot)
+	})
+	go health.Default.Run(ctx, 15*time.Second)
+
+	srv := &http.Server{Addr: cfg.HTTPAddr, Handler: mux}
+	go func() {
+		logger.Log.Infof("HTTP server listening on %s", cfg.HTTPAddr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Log.Errorf("HTTP server stopped: %v", err)
+		}
+	}()
+
 	redisqueue.InitRedisQueue(store)
-	go redisqueue.StartWorkerLoop(bot)
+	redisqueue.InitJournal(journal)
+	redisqueue.InitLifecycles(journal)
+	outbox := redisqueue.NewOutbox(telegram.NewLiveNotifier(bot), cfg.Outbox)
+	redisqueue.InitNotifier(outbox)
+	telegram.InitJournal(journal)
+	outbox.Start(ctx)
+	redisqueue.StartWorkerLoop(ctx, bot)
+
+	live := cfg
+	if err := config.Watch(ctx, []string{config.Path(), cfg.FeesFile}, func() {
+		live = reloadConfig(live)
+	}); err != nil {
+		logger.Log.Errorf("config watcher not started, only a restart applies changes: %v", err)
+	}
+
+	telegram.StartBotWithBot(ctx, bot, store)
 
-	telegram.StartBotWithBot(bot, store)
+	logger.Log.Info("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := redisqueue.Shutdown(shutdownCtx); err != nil {
+		logger.Log.Errorf("workers shutdown: %v", err)
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Log.Errorf("HTTP server shutdown: %v", err)
+	}
+	if err := redisqueue.Close(); err != nil {
+		logger.Log.Errorf("failed to close redis: %v", err)
+	}
+	if err := journal.Close(); err != nil {
+		logger.Log.Errorf("failed to close opportunity journal: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		logger.Log.Errorf("failed to close SQLite store: %v", err)
+	}
+	logger.Log.Info("Shutdown complete")
 }
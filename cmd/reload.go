@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/config"
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/usecase"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/cache"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/parser"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/redisqueue"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+)
+
+// loadFees installs the fee schedule at path; a missing file keeps the
+// current schedule.
+func loadFees(path string) error {
+	fees, err := usecase.LoadFeeSchedule(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		logger.Log.Infof("fee schedule %s not found, using defaults", path)
+		return nil
+	case err != nil:
+		return err
+	}
+	usecase.SetFeeSchedule(fees)
+	return nil
+}
+
+func applySources(cfg *config.Config) {
+	for name, enabled := range cfg.Sources {
+		parser.DefaultRegistry.SetEnabled(name, enabled)
+	}
+}
+
+// reloadConfig loads the config again and applies what can change at
+// runtime: log level, fee schedule, worker tick interval, enabled sources,
+// cache TTL and signal dedup. An invalid config is rejected as a whole.
+func reloadConfig(current *config.Config) *config.Config {
+	next, err := config.Load(config.Path())
+	if err != nil {
+		logger.Log.Errorf("config reload rejected, keeping the current one: %v", err)
+		return current
+	}
+
+	if next.LogLevel != current.LogLevel {
+		logger.SetLevel(next.LogLevel)
+		logger.Log.Infof("Log level: %s", next.LogLevel)
+	}
+	if err := loadFees(next.FeesFile); err != nil {
+		logger.Log.Errorf("fee schedule reload failed, keeping the current one: %v", err)
+	}
+	if next.Worker.TickInterval != current.Worker.TickInterval || next.Worker.FallbackTick != current.Worker.FallbackTick {
+		redisqueue.SetWorkerTiming(next.Worker.TickInterval, next.Worker.FallbackTick)
+	}
+	applySources(next)
+	if next.Cache.TTL != current.Cache.TTL {
+		cache.GlobalOrderCache.SetTTL(next.Cache.TTL)
+		logger.Log.Infof("Cache TTL: %v", next.Cache.TTL)
+	}
+
+	if next.Dedup != current.Dedup {
+		redisqueue.SetDedup(next.Dedup)
+		logger.Log.Infof("Dedup: cooldown %v, margin threshold %v", next.Dedup.Cooldown, next.Dedup.MarginThreshold)
+	}
+
+	for _, key := range current.RestartRequired(next) {
+		logger.Log.Warnf("config: %s changed, restart to apply it", key)
+	}
+	logger.Log.Info("Config reloaded")
+	return next
+}
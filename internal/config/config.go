@@ -0,0 +1,318 @@
+// Package config loads the settings of the service from a YAML file and the
+// environment. Environment variables override the file, the file overrides
+// the defaults.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultFile is read when CONFIG_FILE is not set; it may be absent.
+	DefaultFile = "config.yaml"
+
+	RedisModeSingle   = "single"
+	RedisModeSentinel = "sentinel"
+	RedisModeCluster  = "cluster"
+
+	// LeaseTTL is how long a chat lease lives without renewal; watchdog
+	// rounds renew it, so they must come well within it.
+	LeaseTTL = 45 * time.Second
+)
+
+type Config struct {
+	LogLevel     string `yaml:"log_level"`
+	DBPath       string `yaml:"db_path"`
+	HTTPAddr     string `yaml:"http_addr"`
+	FeesFile     string `yaml:"fees_file"`
+	SnapshotFile string `yaml:"snapshot_file"`
+
+	Telegram Telegram `yaml:"telegram"`
+	Worker   Worker   `yaml:"worker"`
+	Cache    Cache    `yaml:"cache"`
+	Dedup    Dedup    `yaml:"dedup"`
+	Outbox   Outbox   `yaml:"outbox"`
+	Parser   Parser   `yaml:"parser"`
+	// Sources включает и выключает источники по имени, например "grinex USDT/RUB";
+	// ключи, которых нет в файле, берутся из Default
+	Sources map[domain.Source]bool `yaml:"sources"`
+	Redis   Redis                  `yaml:"redis"`
+	Queue   Queue                  `yaml:"queue"`
+}
+
+type Telegram struct {
+	Token        string        `yaml:"token"`
+	PollTimeout  time.Duration `yaml:"poll_timeout"`
+	HistoryLimit int           `yaml:"history_limit"`
+}
+
+type Worker struct {
+	// TickInterval — минимальный интервал между анализами одного чата
+	TickInterval time.Duration `yaml:"tick_interval"`
+	// FallbackTick запускает анализ, даже если стаканы не менялись; не больше stale_after/3
+	FallbackTick     time.Duration `yaml:"fallback_tick"`
+	StaleAfter       time.Duration `yaml:"stale_after"`
+	WatchdogInterval time.Duration `yaml:"watchdog_interval"`
+}
+
+type Cache struct {
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// Dedup decides when a signal that is still there is sent again.
+type Dedup struct {
+	// Cooldown — через сколько тот же сигнал присылается снова
+	Cooldown time.Duration `yaml:"cooldown"`
+	// MarginThreshold — изменение маржи (RUB на единицу), при котором сигнал
+	// присылается снова, не дожидаясь cooldown
+	MarginThreshold float64 `yaml:"margin_threshold"`
+}
+
+// Outbox limits how fast worker messages go to Telegram and how they are
+// retried.
+type Outbox struct {
+	// GlobalRate — сообщений в секунду на весь бот, ChatRate — на один чат
+	GlobalRate  float64 `yaml:"global_rate"`
+	GlobalBurst int     `yaml:"global_burst"`
+	ChatRate    float64 `yaml:"chat_rate"`
+	ChatBurst   int     `yaml:"chat_burst"`
+	// MaxAttempts — после стольких неудачных попыток сообщение выбрасывается
+	MaxAttempts int `yaml:"max_attempts"`
+	// Backoff удваивается с каждой попыткой до MaxBackoff; retry_after от
+	// Telegram важнее
+	Backoff    time.Duration `yaml:"backoff"`
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+}
+
+type Parser struct {
+	// Depth is the number of order book levels taken from every side.
+	Depth         int           `yaml:"depth"`
+	ChromeTimeout time.Duration `yaml:"chrome_timeout"`
+	ParallelLimit int           `yaml:"parallel_limit"`
+	PollInterval  time.Duration `yaml:"poll_interval"`
+	// GrinexAPIURL пустой — только скрейпинг через Chrome
+	GrinexAPIURL string `yaml:"grinex_api_url"`
+}
+
+// Redis describes how to reach Redis in any of the supported modes.
+type Redis struct {
+	Mode             string   `yaml:"mode"`
+	Addrs            []string `yaml:"addrs"` // single: first address; cluster: seed nodes
+	Password         string   `yaml:"password"`
+	DB               int      `yaml:"db"`
+	MasterName       string   `yaml:"master_name"`       // sentinel
+	SentinelAddrs    []string `yaml:"sentinel_addrs"`    // sentinel
+	SentinelPassword string   `yaml:"sentinel_password"` // sentinel
+	TLS              bool     `yaml:"tls"`
+	TLSInsecure      bool     `yaml:"tls_insecure"`
+	TLSCAFile        string   `yaml:"tls_ca_file"`
+}
+
+type Queue struct {
+	Backend string `yaml:"backend"`
+	Mode    string `yaml:"mode"`
+}
+
+// Default returns the settings the service used before it had a config file.
+func Default() *Config {
+	return &Config{
+		LogLevel: "debug",
+		DBPath:   "data.db",
+		HTTPAddr: ":8080",
+		FeesFile: "fees.json",
+		Telegram: Telegram{
+			PollTimeout:  10 * time.Second,
+			HistoryLimit: 10,
+		},
+		Worker: Worker{
+			TickInterval:     2 * time.Second,
+			FallbackTick:     30 * time.Second,
+			StaleAfter:       90 * time.Second,
+			WatchdogInterval: 15 * time.Second,
+		},
+		Cache: Cache{TTL: 60 * time.Second},
+		Dedup: Dedup{
+			Cooldown:        10 * time.Minute,
+			MarginThreshold: 0.05,
+		},
+		Outbox: Outbox{
+			GlobalRate:  25,
+			GlobalBurst: 25,
+			ChatRate:    1,
+			ChatBurst:   3,
+			MaxAttempts: 5,
+			Backoff:     time.Second,
+			MaxBackoff:  time.Minute,
+		},
+		Parser: Parser{
+			Depth:         5,
+			ChromeTimeout: 40 * time.Second,
+			ParallelLimit: 1,
+			PollInterval:  5 * time.Second,
+			GrinexAPIURL:  "https://grinex.io",
+		},
+		Sources: map[domain.Source]bool{
+			domain.RapiraSource:         true,
+			domain.GrinexUSDTRUBSource:  false,
+			domain.GrinexUSDTA7A5Source: true,
+			domain.GrinexA7A5RUBSource:  true,
+		},
+		Redis: Redis{
+			Mode:  RedisModeSingle,
+			Addrs: []string{"redis-internal:6379"},
+		},
+		Queue: Queue{Backend: "list", Mode: "blpop"},
+	}
+}
+
+// Path returns the config file to read: CONFIG_FILE or DefaultFile.
+func Path() string {
+	if p := os.Getenv("CONFIG_FILE"); p != "" {
+		return p
+	}
+	return DefaultFile
+}
+
+// Load reads the file at path over the defaults, applies the environment and
+// validates the result. A missing file is fine unless CONFIG_FILE names it.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && os.Getenv("CONFIG_FILE") == "":
+	case err != nil:
+		return nil, fmt.Errorf("read config: %w", err)
+	default:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true) // опечатка в ключе — ошибка, а не молчаливый дефолт
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, fmt.Errorf("invalid environment: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+var (
+	logLevels     = []string{"trace", "debug", "info", "warn", "error", "fatal", "panic"}
+	queueBackends = []string{"list", "streams"}
+	queueModes    = []string{"blpop", "reliable"}
+	knownSources  = []domain.Source{
+		domain.RapiraSource,
+		domain.GrinexUSDTRUBSource,
+		domain.GrinexUSDTA7A5Source,
+		domain.GrinexA7A5RUBSource,
+	}
+)
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(oneOf(c.LogLevel, logLevels), "log_level %q: want one of %v", c.LogLevel, logLevels)
+	check(c.DBPath != "", "db_path is empty")
+	check(c.HTTPAddr != "", "http_addr is empty")
+	check(c.Telegram.Token != "", "telegram.token is empty (set TELEGRAM_BOT_TOKEN)")
+	check(c.Telegram.PollTimeout >= time.Second, "telegram.poll_timeout %v: want at least 1s", c.Telegram.PollTimeout)
+	check(c.Telegram.HistoryLimit > 0, "telegram.history_limit %d: want > 0", c.Telegram.HistoryLimit)
+
+	check(c.Worker.TickInterval > 0, "worker.tick_interval %v: want > 0", c.Worker.TickInterval)
+	check(c.Worker.FallbackTick >= c.Worker.TickInterval, "worker.fallback_tick %v: want >= tick_interval", c.Worker.FallbackTick)
+	check(c.Worker.WatchdogInterval > 0, "worker.watchdog_interval %v: want > 0", c.Worker.WatchdogInterval)
+	check(c.Worker.StaleAfter > c.Worker.WatchdogInterval, "worker.stale_after %v: want > watchdog_interval", c.Worker.StaleAfter)
+	// аренду продлевает только раунд watchdog: один пропущенный раунд не должен
+	// отдавать чат другому инстансу
+	check(c.Worker.WatchdogInterval < LeaseTTL/2, "worker.watchdog_interval %v: want < %v (half the chat lease TTL)", c.Worker.WatchdogInterval, LeaseTTL/2)
+	// HB обновляется раз в fallback_tick плюс длительность тика (холодный кэш — до
+	// chrome_timeout на запрос), запас нужен, чтобы watchdog не убивал живой воркер
+	check(c.Worker.FallbackTick <= c.Worker.StaleAfter/3, "worker.fallback_tick %v: want at most stale_after/3 (%v)", c.Worker.FallbackTick, c.Worker.StaleAfter/3)
+	check(c.Cache.TTL > 0, "cache.ttl %v: want > 0", c.Cache.TTL)
+	check(c.Dedup.Cooldown > 0, "dedup.cooldown %v: want > 0", c.Dedup.Cooldown)
+	check(c.Dedup.MarginThreshold >= 0, "dedup.margin_threshold %v: want >= 0", c.Dedup.MarginThreshold)
+	check(c.Outbox.GlobalRate > 0, "outbox.global_rate %v: want > 0", c.Outbox.GlobalRate)
+	check(c.Outbox.GlobalBurst > 0, "outbox.global_burst %d: want > 0", c.Outbox.GlobalBurst)
+	check(c.Outbox.ChatRate > 0, "outbox.chat_rate %v: want > 0", c.Outbox.ChatRate)
+	check(c.Outbox.ChatBurst > 0, "outbox.chat_burst %d: want > 0", c.Outbox.ChatBurst)
+	check(c.Outbox.MaxAttempts > 0, "outbox.max_attempts %d: want > 0", c.Outbox.MaxAttempts)
+	check(c.Outbox.Backoff > 0, "outbox.backoff %v: want > 0", c.Outbox.Backoff)
+	check(c.Outbox.MaxBackoff >= c.Outbox.Backoff, "outbox.max_backoff %v: want >= backoff", c.Outbox.MaxBackoff)
+
+	check(c.Parser.Depth > 0 && c.Parser.Depth <= 100, "parser.depth %d: want 1..100", c.Parser.Depth)
+	check(c.Parser.ChromeTimeout > 0, "parser.chrome_timeout %v: want > 0", c.Parser.ChromeTimeout)
+	check(c.Parser.ParallelLimit > 0, "parser.parallel_limit %d: want > 0", c.Parser.ParallelLimit)
+	check(c.Parser.PollInterval > 0, "parser.poll_interval %v: want > 0", c.Parser.PollInterval)
+	for name := range c.Sources {
+		check(knownSource(name), "sources: unknown source %q", name)
+	}
+
+	if err := c.Redis.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("redis: %w", err))
+	}
+	check(oneOf(c.Queue.Backend, queueBackends), "queue.backend %q: want one of %v", c.Queue.Backend, queueBackends)
+	check(oneOf(c.Queue.Mode, queueModes), "queue.mode %q: want one of %v", c.Queue.Mode, queueModes)
+	// BLMOVE/LMOVE между jobs:queue и processing-листами — разные слоты в кластере
+	check(!(c.Queue.Backend == "list" && c.Queue.Mode == "reliable" && c.Redis.Mode == RedisModeCluster),
+		"queue.mode reliable is not supported with redis cluster, use the streams backend")
+
+	return errors.Join(errs...)
+}
+
+func (r Redis) Validate() error {
+	switch r.Mode {
+	case RedisModeSingle, RedisModeCluster:
+		if len(r.Addrs) == 0 {
+			return fmt.Errorf("%s mode needs at least one address", r.Mode)
+		}
+	case RedisModeSentinel:
+		if r.MasterName == "" {
+			return errors.New("sentinel mode needs a master name")
+		}
+		if len(r.SentinelAddrs) == 0 && len(r.Addrs) == 0 {
+			return errors.New("sentinel mode needs sentinel addresses")
+		}
+	default:
+		return fmt.Errorf("unknown mode %q (want single, sentinel or cluster)", r.Mode)
+	}
+	if r.Mode == RedisModeCluster && r.DB != 0 {
+		return errors.New("cluster supports only DB 0")
+	}
+	return nil
+}
+
+func oneOf(v string, allowed []string) bool {
+	for _, a := range allowed {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
+
+func knownSource(name domain.Source) bool {
+	for _, s := range knownSources {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+)
+
+// envReader applies the variables that are set and collects parse errors.
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) str(name string, dst *string) {
+	if v, ok := os.LookupEnv(name); ok {
+		*dst = v
+	}
+}
+
+// nonEmpty is str for settings where an empty variable means "not set".
+func (e *envReader) nonEmpty(name string, dst *string) {
+	if v := os.Getenv(name); v != "" {
+		*dst = v
+	}
+}
+
+func (e *envReader) list(name string, dst *[]string) {
+	if v := os.Getenv(name); v != "" {
+		*dst = splitList(v)
+	}
+}
+
+func (e *envReader) int(name string, dst *int) {
+	if v := os.Getenv(name); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("invalid %s=%q: %w", name, v, err))
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e *envReader) bool(name string, dst *bool) {
+	if v := os.Getenv(name); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("invalid %s=%q: %w", name, v, err))
+			return
+		}
+		*dst = b
+	}
+}
+
+func (e *envReader) float(name string, dst *float64) {
+	if v := os.Getenv(name); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("invalid %s=%q: %w", name, v, err))
+			return
+		}
+		*dst = f
+	}
+}
+
+func (e *envReader) duration(name string, dst *time.Duration) {
+	if v := os.Getenv(name); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("invalid %s=%q: %w", name, v, err))
+			return
+		}
+		*dst = d
+	}
+}
+
+func (e *envReader) source(name string, src domain.Source, sources map[domain.Source]bool) {
+	if os.Getenv(name) == "" {
+		return
+	}
+	enabled := sources[src]
+	e.bool(name, &enabled)
+	sources[src] = enabled
+}
+
+// applyEnv overrides the settings with the environment variables that are set.
+func (c *Config) applyEnv() error {
+	var e envReader
+
+	e.nonEmpty("LOG_LEVEL", &c.LogLevel)
+	e.nonEmpty("DB_PATH", &c.DBPath)
+	e.nonEmpty("HTTP_ADDR", &c.HTTPAddr)
+	e.nonEmpty("FEES_FILE", &c.FeesFile)
+	e.nonEmpty("SNAPSHOT_FILE", &c.SnapshotFile)
+
+	e.nonEmpty("TELEGRAM_BOT_TOKEN", &c.Telegram.Token)
+	e.duration("TELEGRAM_POLL_TIMEOUT", &c.Telegram.PollTimeout)
+	e.int("HISTORY_LIMIT", &c.Telegram.HistoryLimit)
+
+	e.duration("WORKER_TICK_INTERVAL", &c.Worker.TickInterval)
+	e.duration("WORKER_FALLBACK_TICK", &c.Worker.FallbackTick)
+	e.duration("WORKER_STALE_AFTER", &c.Worker.StaleAfter)
+	e.duration("WATCHDOG_INTERVAL", &c.Worker.WatchdogInterval)
+
+	e.duration("CACHE_TTL", &c.Cache.TTL)
+	e.duration("DEDUP_COOLDOWN", &c.Dedup.Cooldown)
+	e.float("DEDUP_MARGIN_THRESHOLD", &c.Dedup.MarginThreshold)
+	e.float("OUTBOX_GLOBAL_RATE", &c.Outbox.GlobalRate)
+	e.float("OUTBOX_CHAT_RATE", &c.Outbox.ChatRate)
+	e.int("OUTBOX_MAX_ATTEMPTS", &c.Outbox.MaxAttempts)
+
+	e.int("PARSER_DEPTH", &c.Parser.Depth)
+	e.duration("CHROME_TIMEOUT", &c.Parser.ChromeTimeout)
+	e.int("CHROME_PARALLEL_LIMIT", &c.Parser.ParallelLimit)
+	e.duration("POLL_INTERVAL", &c.Parser.PollInterval)
+	// пустой GRINEX_API_URL отключает API, поэтому LookupEnv
+	e.str("GRINEX_API_URL", &c.Parser.GrinexAPIURL)
+
+	if c.Sources == nil {
+		c.Sources = map[domain.Source]bool{}
+	}
+	e.source("GRINEX_USDTRUB_ENABLED", domain.GrinexUSDTRUBSource, c.Sources)
+
+	e.nonEmpty("REDIS_MODE", &c.Redis.Mode)
+	e.list("REDIS_ADDR", &c.Redis.Addrs)
+	e.nonEmpty("REDIS_PASSWORD", &c.Redis.Password)
+	e.int("REDIS_DB", &c.Redis.DB)
+	e.nonEmpty("REDIS_MASTER_NAME", &c.Redis.MasterName)
+	e.list("REDIS_SENTINEL_ADDRS", &c.Redis.SentinelAddrs)
+	e.nonEmpty("REDIS_SENTINEL_PASSWORD", &c.Redis.SentinelPassword)
+	e.bool("REDIS_TLS", &c.Redis.TLS)
+	e.bool("REDIS_TLS_INSECURE", &c.Redis.TLSInsecure)
+	e.nonEmpty("REDIS_TLS_CA_FILE", &c.Redis.TLSCAFile)
+
+	e.nonEmpty("JOB_QUEUE_BACKEND", &c.Queue.Backend)
+	e.nonEmpty("JOB_QUEUE_MODE", &c.Queue.Mode)
+
+	return errors.Join(e.errs...)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
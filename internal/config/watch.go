@@ -0,0 +1,115 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay собирает пачку событий одного сохранения файла в одну перезагрузку
+const reloadDelay = 500 * time.Millisecond
+
+// Watch calls reload after any of the files at paths changes and on SIGHUP,
+// until ctx is done. The directories are watched, so a file may be absent or
+// replaced by rename, as editors do. reload is never called concurrently.
+func Watch(ctx context.Context, paths []string, reload func()) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			_ = w.Close()
+			return err
+		}
+		files[abs] = true
+		if dir := filepath.Dir(abs); !dirs[dir] {
+			if err := w.Add(dir); err != nil {
+				_ = w.Close()
+				return err
+			}
+			dirs[dir] = true
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer w.Close()
+		defer signal.Stop(hup)
+
+		var (
+			debounce  *time.Timer
+			debounceC <-chan time.Time
+		)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				logger.Log.Info("SIGHUP: reloading config")
+				reload()
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if !files[filepath.Clean(ev.Name)] || ev.Op == fsnotify.Chmod {
+					continue
+				}
+				if debounce == nil {
+					debounce = time.NewTimer(reloadDelay)
+				} else {
+					debounce.Reset(reloadDelay)
+				}
+				debounceC = debounce.C
+			case <-debounceC:
+				debounceC = nil
+				logger.Log.Info("Config files changed: reloading")
+				reload()
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				logger.Log.WithError(err).Warn("config watcher error")
+			}
+		}
+	}()
+	return nil
+}
+
+// RestartRequired lists the settings that differ in next but are only read
+// at startup.
+func (c *Config) RestartRequired(next *Config) []string {
+	var out []string
+	diff := func(name string, a, b any) {
+		if !reflect.DeepEqual(a, b) {
+			out = append(out, name)
+		}
+	}
+	diff("db_path", c.DBPath, next.DBPath)
+	diff("http_addr", c.HTTPAddr, next.HTTPAddr)
+	diff("snapshot_file", c.SnapshotFile, next.SnapshotFile)
+	diff("telegram", c.Telegram, next.Telegram)
+	diff("worker.stale_after", c.Worker.StaleAfter, next.Worker.StaleAfter)
+	diff("worker.watchdog_interval", c.Worker.WatchdogInterval, next.Worker.WatchdogInterval)
+	diff("outbox", c.Outbox, next.Outbox)
+	diff("parser", c.Parser, next.Parser)
+	diff("redis", c.Redis, next.Redis)
+	diff("queue", c.Queue, next.Queue)
+	return out
+}
@@ -0,0 +1,82 @@
+package domain
+
+// Filters narrow the signals sent to a chat; the zero value sends every
+// signal type from every venue. Disabled ones are listed, so that a venue
+// added later is on for everybody.
+type Filters struct {
+	DisabledSources []Source
+	DisabledSignals []SignalType
+}
+
+// SignalTypes lists the signal types in the order they are detected.
+var SignalTypes = []SignalType{SignalFact, SignalDepth, SignalTriangle, SignalPotential, SignalReverse}
+
+var signalTypeNames = map[SignalType]string{
+	SignalFact:      "фактический",
+	SignalDepth:     "по стакану",
+	SignalTriangle:  "треугольный",
+	SignalPotential: "потенциальный",
+	SignalReverse:   "обратный",
+}
+
+// SignalTypeName returns the short Russian name of t.
+func SignalTypeName(t SignalType) string {
+	if n, ok := signalTypeNames[t]; ok {
+		return n
+	}
+	return string(t)
+}
+
+func (f Filters) SourceEnabled(s Source) bool {
+	for _, d := range f.DisabledSources {
+		if d == s {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Filters) SignalEnabled(t SignalType) bool {
+	for _, d := range f.DisabledSignals {
+		if d == t {
+			return false
+		}
+	}
+	return true
+}
+
+// SourcesEnabled reports whether every one of sources is enabled.
+func (f Filters) SourcesEnabled(sources ...Source) bool {
+	for _, s := range sources {
+		if !f.SourceEnabled(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *Filters) ToggleSource(s Source) {
+	if f.SourceEnabled(s) {
+		f.DisabledSources = append(f.DisabledSources, s)
+		return
+	}
+	f.DisabledSources = removeItem(f.DisabledSources, s)
+}
+
+func (f *Filters) ToggleSignal(t SignalType) {
+	if f.SignalEnabled(t) {
+		f.DisabledSignals = append(f.DisabledSignals, t)
+		return
+	}
+	f.DisabledSignals = removeItem(f.DisabledSignals, t)
+}
+
+func removeItem[T comparable](items []T, v T) []T {
+	out := items[:0]
+	for _, it := range items {
+		if it != v {
+			out = append(out, it)
+		}
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+type LifecycleEvent string
+
+const (
+	LifecycleOpened   LifecycleEvent = "opened"
+	LifecycleImproved LifecycleEvent = "improved"
+	LifecycleWorsened LifecycleEvent = "worsened"
+	LifecycleClosed   LifecycleEvent = "closed"
+)
+
+// Lifecycle is one opportunity of a chat followed across ticks, from the
+// first tick it was found to the first tick it was gone.
+type Lifecycle struct {
+	ID           int64
+	ChatID       int64
+	Key          string // usecase.SignalKey / TriangleKey
+	Type         SignalType
+	BuyExchange  Source
+	SellExchange Source
+	BuyPair      Pair
+	SellPair     Pair
+	OpenMargin   float64
+	PeakMargin   float64
+	LastMargin   float64
+	OpenedAt     time.Time
+	UpdatedAt    time.Time // last tick it was seen
+	ClosedAt     time.Time // zero while open
+}
+
+func (l *Lifecycle) IsOpen() bool { return l.ClosedAt.IsZero() }
+
+// Duration is how long the opportunity lasted, or has lasted so far.
+func (l *Lifecycle) Duration() time.Duration {
+	if l.IsOpen() {
+		return l.UpdatedAt.Sub(l.OpenedAt)
+	}
+	return l.ClosedAt.Sub(l.OpenedAt)
+}
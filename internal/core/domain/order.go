@@ -6,6 +6,7 @@ type OrderSide string
 type Pair string
 type Direction string
 type Source string
+type SignalType string
 
 const(
 	SideBuy	 OrderSide 	= "buy"			//красный стакан
@@ -14,6 +15,7 @@ const(
 	RapiraSource Source = "rapira"
 	GrinexUSDTRUBSource Source = "grinex USDT/RUB"
 	GrinexUSDTA7A5Source Source = "grinex USDT/A7A5"
+	GrinexA7A5RUBSource Source = "grinex A7A5/RUB"
 
 	Usdta7a5	 Pair = "USDT/A7A5" 
 	Usdtrub		 Pair = "USDT/RUB"
@@ -22,6 +24,12 @@ const(
 	USDT		 Direction = "USDT"
 	A7A5		 Direction = "A7A5"
 	RUB		 	 Direction = "RUB"
+
+	SignalFact		SignalType = "fact"
+	SignalPotential	SignalType = "potential"
+	SignalReverse	SignalType = "reverse"
+	SignalDepth		SignalType = "depth"
+	SignalTriangle	SignalType = "triangle"
 )
 
 type Order struct {
@@ -43,5 +51,36 @@ type Opportunity struct {
 	BuyAmount     float64
 	ProfitMargin  float64    
 	SuggestedBid  float64    
+	Volume        float64    // исполнимый объем при проходе по стакану
+	TotalProfit   float64    // RUB
+	ProfitPercent float64
 	CreatedAt     time.Time
-}
\ No newline at end of file
+}
+
+// OrderBook is a snapshot of both sides of a single market on one venue.
+type OrderBook struct {
+	Source 	Source
+	Pair   	Pair
+	Asks   	[]*Order	// красный стакан
+	Bids   	[]*Order	// зеленый стакан
+}
+
+// CycleLeg is one conversion of a triangular cycle: From is spent on Source to get To.
+type CycleLeg struct {
+	Source 	Source
+	Pair   	Pair
+	From   	Direction
+	To     	Direction
+	Price  	float64
+}
+
+// TriangleOpportunity is a profitable RUB → … → RUB cycle through three books.
+type TriangleOpportunity struct {
+	Legs          []CycleLeg
+	StartAmount   float64    // RUB
+	EndAmount     float64    // RUB
+	Volume        float64    // USDT, прошедший через цикл
+	TotalProfit   float64    // RUB
+	ProfitPercent float64
+	CreatedAt     time.Time
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule is the notification schedule of a chat; the zero value means
+// the global tick interval and signals around the clock.
+type Schedule struct {
+	// TickInterval — не чаще одного анализа за интервал; 0 — глобальный worker.tick_interval
+	TickInterval time.Duration
+	// QuietFrom и QuietTo — минуты от полуночи; равные значения — тихих часов нет.
+	// Окно может переходить через полночь (23:00-08:00).
+	QuietFrom int
+	QuietTo   int
+	// Timezone is an IANA zone name; empty means UTC.
+	Timezone string
+	// Weekdays has bit 1<<time.Weekday set for every active day; 0 means every day.
+	Weekdays uint8
+}
+
+// AllWeekdays is the Weekdays mask with every day set.
+const AllWeekdays uint8 = 1<<7 - 1
+
+var weekdayNames = [7]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
+
+// WeekdayName returns the short Russian name of d.
+func WeekdayName(d time.Weekday) string { return weekdayNames[d] }
+
+func (s Schedule) Location() *time.Location {
+	if s.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func (s Schedule) HasQuietHours() bool { return s.QuietFrom != s.QuietTo }
+
+// Paused reports whether signals must not be sent at t: during quiet hours
+// or on an inactive weekday, both in the schedule's time zone.
+func (s Schedule) Paused(t time.Time) bool {
+	lt := t.In(s.Location())
+	if s.Weekdays != 0 && s.Weekdays&(1<<uint(lt.Weekday())) == 0 {
+		return true
+	}
+	if !s.HasQuietHours() {
+		return false
+	}
+	m := lt.Hour()*60 + lt.Minute()
+	if s.QuietFrom < s.QuietTo {
+		return m >= s.QuietFrom && m < s.QuietTo
+	}
+	return m >= s.QuietFrom || m < s.QuietTo
+}
+
+// String describes the schedule for the chat.
+func (s Schedule) String() string {
+	var b strings.Builder
+
+	if s.TickInterval > 0 {
+		fmt.Fprintf(&b, "Интервал анализа: не чаще раза в %v\n", s.TickInterval)
+	} else {
+		b.WriteString("Интервал анализа: по умолчанию\n")
+	}
+
+	tz := s.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	if s.HasQuietHours() {
+		fmt.Fprintf(&b, "Тихие часы: %02d:%02d–%02d:%02d (%s)\n",
+			s.QuietFrom/60, s.QuietFrom%60, s.QuietTo/60, s.QuietTo%60, tz)
+	} else {
+		b.WriteString("Тихие часы: нет\n")
+	}
+
+	if s.Weekdays == 0 || s.Weekdays == AllWeekdays {
+		b.WriteString("Дни: каждый день")
+	} else {
+		var days []string
+		// с понедельника по воскресенье
+		for i := 1; i <= 7; i++ {
+			d := time.Weekday(i % 7)
+			if s.Weekdays&(1<<uint(d)) != 0 {
+				days = append(days, weekdayNames[d])
+			}
+		}
+		fmt.Fprintf(&b, "Дни: %s (%s)", strings.Join(days, ", "), tz)
+	}
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // Europe/Moscow без системной базы поясов
+)
+
+func TestSchedulePaused(t *testing.T) {
+	weekdays := func(days ...time.Weekday) uint8 {
+		var m uint8
+		for _, d := range days {
+			m |= 1 << uint(d)
+		}
+		return m
+	}
+	// 2026-01-02 — пятница
+	utc := func(day, hour, min int) time.Time { return time.Date(2026, 1, day, hour, min, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name  string
+		sched Schedule
+		at    time.Time
+		want  bool
+	}{
+		{"zero schedule", Schedule{}, utc(2, 3, 0), false},
+		{"inside same-day window", Schedule{QuietFrom: 13 * 60, QuietTo: 14 * 60}, utc(2, 13, 30), true},
+		{"window end is exclusive", Schedule{QuietFrom: 13 * 60, QuietTo: 14 * 60}, utc(2, 14, 0), false},
+		{"window start is inclusive", Schedule{QuietFrom: 13 * 60, QuietTo: 14 * 60}, utc(2, 13, 0), true},
+		{"across midnight, late evening", Schedule{QuietFrom: 23 * 60, QuietTo: 8 * 60}, utc(2, 23, 30), true},
+		{"across midnight, early morning", Schedule{QuietFrom: 23 * 60, QuietTo: 8 * 60}, utc(3, 7, 59), true},
+		{"across midnight, daytime", Schedule{QuietFrom: 23 * 60, QuietTo: 8 * 60}, utc(2, 12, 0), false},
+		{"across midnight, at end", Schedule{QuietFrom: 23 * 60, QuietTo: 8 * 60}, utc(3, 8, 0), false},
+		{"quiet hours in zone", Schedule{QuietFrom: 23 * 60, QuietTo: 8 * 60, Timezone: "Europe/Moscow"}, utc(2, 21, 0), true},
+		{"quiet hours over in zone", Schedule{QuietFrom: 23 * 60, QuietTo: 8 * 60, Timezone: "Europe/Moscow"}, utc(2, 5, 0), false},
+		{"active weekday", Schedule{Weekdays: weekdays(time.Friday)}, utc(2, 12, 0), false},
+		{"inactive weekday", Schedule{Weekdays: weekdays(time.Monday)}, utc(2, 12, 0), true},
+		// 22:00 UTC пятницы — уже суббота в Москве
+		{"weekday in zone", Schedule{Weekdays: weekdays(time.Friday), Timezone: "Europe/Moscow"}, utc(2, 22, 0), true},
+		{"weekday in zone before midnight", Schedule{Weekdays: weekdays(time.Friday), Timezone: "Europe/Moscow"}, utc(2, 20, 59), false},
+		{"unknown zone is UTC", Schedule{Weekdays: weekdays(time.Friday), Timezone: "Nowhere/City"}, utc(2, 22, 0), false},
+		{"all weekdays", Schedule{Weekdays: AllWeekdays}, utc(4, 12, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sched.Paused(tt.at); got != tt.want {
+				t.Errorf("Paused(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
@@ -9,6 +9,8 @@ type UserState struct {
 	MinDiff float64
 	MaxSum   float64
 	Step    string		//"waiting_foe_input", "ready_to_run", etc.
+	Schedule Schedule
+	Filters  Filters
 }
 
 
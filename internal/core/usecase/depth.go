@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+)
+
+// DetectDepthArbitrage buys by taking asks of sourceAsk and sells by hitting
+// bids of sourceBid, walking both books level by level while every next unit
+// still earns at least minDiff and the spent RUB stays within maxSum.
+// It returns nil if nothing can be filled with a profit.
+func DetectDepthArbitrage(
+	asks []*domain.Order,
+	bids []*domain.Order,
+	minDiff, maxSum float64,
+	fees *FeeSchedule,
+	sourceAsk, sourceBid domain.Source,
+	pair domain.Pair,
+) (*domain.Opportunity, error) {
+	if len(asks) == 0 || len(bids) == 0 {
+		return nil, errors.New("empty orderbook")
+	}
+
+	asks = sortedLevels(asks, func(a, b *domain.Order) bool { return a.Price < b.Price })
+	bids = sortedLevels(bids, func(a, b *domain.Order) bool { return a.Price > b.Price })
+
+	var (
+		volume, spent, proceeds float64
+		rawBuy, rawSell         float64
+		askLeft                 = asks[0].Amount
+		bidLeft                 = bids[0].Amount
+	)
+	for i, j := 0, 0; i < len(asks) && j < len(bids); {
+		ask, bid := asks[i], bids[j]
+		effectiveBuy := ask.Price * (1 + fees.Venue(sourceAsk, ask.Pair).Taker)
+		effectiveSell := bid.Price / (1 + fees.Venue(sourceBid, bid.Pair).Taker)
+		if effectiveSell-effectiveBuy < minDiff {
+			break
+		}
+
+		qty := math.Min(askLeft, bidLeft)
+		if maxSum > 0 {
+			qty = math.Min(qty, (maxSum-spent)/effectiveBuy)
+		}
+		if qty <= 0 {
+			break
+		}
+
+		volume += qty
+		spent += qty * effectiveBuy
+		proceeds += qty * effectiveSell
+		rawBuy += qty * ask.Price
+		rawSell += qty * bid.Price
+
+		askLeft -= qty
+		bidLeft -= qty
+		if askLeft <= 0 {
+			if i++; i < len(asks) {
+				askLeft = asks[i].Amount
+			}
+		}
+		if bidLeft <= 0 {
+			if j++; j < len(bids) {
+				bidLeft = bids[j].Amount
+			}
+		}
+		if maxSum > 0 && spent >= maxSum {
+			break
+		}
+	}
+
+	if volume == 0 {
+		return nil, nil
+	}
+
+	profit := proceeds - spent - fees.Transfer(sourceAsk, sourceBid)
+	margin := profit / volume
+	if profit <= 0 || margin < minDiff {
+		return nil, nil
+	}
+
+	opp := &domain.Opportunity{
+		BuyExchange:   sourceAsk,
+		SellExchange:  sourceBid,
+		BuyPrice:      math.Round(rawBuy/volume*100) / 100,
+		SellPrice:     math.Round(rawSell/volume*100) / 100,
+		BuyPair:       pair,
+		SellPair:      pair,
+		BuyAmount:     volume,
+		ProfitMargin:  math.Round(margin*100) / 100,
+		Volume:        volume,
+		TotalProfit:   math.Round(profit*100) / 100,
+		ProfitPercent: math.Round(profit/spent*100*1000) / 1000,
+		CreatedAt:     time.Now(),
+	}
+	logger.Log.Infof(
+		"Found depth arbitrage: Buy %s @ %.2f, Sell %s @ %.2f, Volume: %.4f, Profit: %.2f RUB (%.3f%%)",
+		sourceAsk, opp.BuyPrice, sourceBid, opp.SellPrice, volume, opp.TotalProfit, opp.ProfitPercent,
+	)
+	return opp, nil
+}
+
+// DetectDepth takes the current order books and looks for arbitrage executable across their depth.
+func DetectDepth(minDiff, maxSum float64, chatID int64) ([]*domain.Opportunity, error) {
+	return DetectDepthFromBooks(CurrentBooks(), minDiff, maxSum)
+}
+
+// DetectDepthFromBooks runs DetectDepthArbitrage over every (ask book, bid book) combination.
+func DetectDepthFromBooks(books []*domain.OrderBook, minDiff, maxSum float64) ([]*domain.Opportunity, error) {
+	opps := []*domain.Opportunity{}
+	fees := Fees()
+
+	for _, bp := range pairMatrix(books) {
+		if bp.ask == bp.bid || len(bp.ask.Asks) == 0 || len(bp.bid.Bids) == 0 {
+			continue
+		}
+		opp, err := DetectDepthArbitrage(bp.ask.Asks, bp.bid.Bids, minDiff, maxSum,
+			fees, bp.ask.Source, bp.bid.Source, bp.ask.Pair)
+		if err != nil {
+			logger.Log.Errorf("failed to detect depth arbitrage: %v", err)
+			continue
+		}
+		if opp != nil {
+			opps = append(opps, opp)
+		}
+	}
+
+	return opps, nil
+}
+
+// sortedLevels returns a sorted copy, so the cached books are left untouched.
+func sortedLevels(levels []*domain.Order, less func(a, b *domain.Order) bool) []*domain.Order {
+	out := make([]*domain.Order, len(levels))
+	copy(out, levels)
+	sort.SliceStable(out, func(i, j int) bool { return less(out[i], out[j]) })
+	return out
+}
@@ -2,30 +2,18 @@ package usecase
 
 import (
 	"errors"
-	"fmt"
 	"math"
-	"sync"
 	"time"
 
 	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
 	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
 )
 
-/*
-На данный момент пара USDT/RUB на Grinex не работает, поэтому эта часть кода закомментирована
-Как только пара будет восстановлена, вернем рассмотрение и этих ситуаций
-*/
-
-
-var (
-	recentMu sync.Mutex
-	RecentHashes = make(map[string]time.Time)
-)
-
 func DetectPairArbitrage(
 	asks []*domain.Order,
 	bids []*domain.Order,
-	minDiff, maxSum, feeAsk, feeBid float64,
+	minDiff, maxSum float64,
+	fees *FeeSchedule,
 	sourceAsk, sourceBid domain.Source,
 	pair domain.Pair,
 ) ([]*domain.Opportunity, error) {
@@ -49,10 +37,11 @@ func DetectPairArbitrage(
 			return opps, nil
 		}
 
-			// Effective prices
-		effectiveAsk := ask.Price / (1 + feeAsk)
-		effectiveBid := bid.Price * (1 + feeBid)
-		profit := math.Round((effectiveAsk - effectiveBid)*100) / 100
+			// Effective prices (лимитные заявки — maker)
+		effectiveAsk := ask.Price / (1 + fees.Venue(sourceAsk, ask.Pair).Maker)
+		effectiveBid := bid.Price * (1 + fees.Venue(sourceBid, bid.Pair).Maker)
+		transfer := fees.transferPerUnit(sourceBid, sourceAsk, bid.Amount)
+		profit := math.Round((effectiveAsk - effectiveBid - transfer)*100) / 100
 
 		logger.Log.Infof("Checking ask %.2f (eff %.4f) vs bid %.2f (eff %.4f) = profit %.4f",
 			ask.Price, effectiveAsk, bid.Price, effectiveBid, profit)
@@ -61,9 +50,11 @@ func DetectPairArbitrage(
 			opportunity := &domain.Opportunity{
 				BuyExchange:   	sourceBid,
 				SellExchange:  	sourceAsk,
-				ProfitMargin:  	math.Round((effectiveAsk - effectiveBid)*100) / 100,
+				ProfitMargin:  	profit,
 				BuyPrice: 	   	math.Round(bid.Price*100) / 100,
 				SellPrice: 	   	math.Round(ask.Price*100) / 100,
+				BuyPair:       	bid.Pair,
+				SellPair:      	ask.Pair,
 				BuyAmount:     	bid.Amount,
 				SuggestedBid:  	bid.Price + 0.01,
 				CreatedAt:     	time.Now(),
@@ -83,14 +74,17 @@ func DetectPairArbitrage(
 func DetectFactArbitrage(
 	ask *domain.Order,
 	bid *domain.Order,
-	minDiff, maxSum, feeAsk, feeBid float64,
+	minDiff, maxSum float64,
+	fees *FeeSchedule,
 	sourceAsk, sourceBid domain.Source,
 	pair domain.Pair,
 ) ([]*domain.Opportunity, error) {
 	opps := []*domain.Opportunity{}
-	effectiveAsk := ask.Price / (1 + feeAsk)
-	effectiveBid := bid.Price * (1 + feeBid)
-	profit := math.Round((effectiveAsk - effectiveBid)*100) / 100
+	// Сделка по лучшим ценам сразу — taker
+	effectiveAsk := ask.Price / (1 + fees.Venue(sourceAsk, ask.Pair).Taker)
+	effectiveBid := bid.Price * (1 + fees.Venue(sourceBid, bid.Pair).Taker)
+	transfer := fees.transferPerUnit(sourceBid, sourceAsk, bid.Amount)
+	profit := math.Round((effectiveAsk - effectiveBid - transfer)*100) / 100
 	
 	logger.Log.Infof("Checking ask %.2f (eff %.4f) vs bid %.2f (eff %.4f) = profit %.4f",
 		ask.Price, effectiveAsk, bid.Price, effectiveBid, profit)
@@ -102,6 +96,8 @@ func DetectFactArbitrage(
 			ProfitMargin:  	profit,
 			BuyPrice: 	   	math.Round(bid.Price*100) / 100,
 			SellPrice: 	   	math.Round(ask.Price*100) / 100,
+			BuyPair:       	bid.Pair,
+			SellPair:      	ask.Pair,
 			BuyAmount:     	bid.Amount,
 			SuggestedBid:  	bid.Price + 0.01,
 			CreatedAt:     	time.Now(),
@@ -121,7 +117,8 @@ func DetectFactArbitrage(
 func DetectPairPotential(
 	asks []*domain.Order,
 	bids []*domain.Order,
-	minDiff, maxSum, feeAsk, feeBid float64,
+	minDiff, maxSum float64,
+	fees *FeeSchedule,
 	sourceAsk, sourceBid domain.Source,
 	pair domain.Pair,
 ) ([]*domain.Opportunity, error) {
@@ -145,10 +142,11 @@ func DetectPairPotential(
 			return opps, nil
 		}
 
-			// Effective prices
-		effectiveAsk := ask.Price / (1 + feeAsk)
-		effectiveBid := bid.Price * (1 + feeBid)
-		profit := math.Round((effectiveAsk - effectiveBid)*100) / 100
+			// Effective prices (лимитные заявки — maker)
+		effectiveAsk := ask.Price / (1 + fees.Venue(sourceAsk, ask.Pair).Maker)
+		effectiveBid := bid.Price * (1 + fees.Venue(sourceBid, bid.Pair).Maker)
+		transfer := fees.transferPerUnit(sourceBid, sourceAsk, bid.Amount)
+		profit := math.Round((effectiveAsk - effectiveBid - transfer)*100) / 100
 
 		logger.Log.Infof("Checking ask %.2f (eff %.4f) vs bid %.2f (eff %.4f) = profit %.4f",
 			ask.Price, effectiveAsk, bid.Price, effectiveBid, profit)
@@ -160,6 +158,8 @@ func DetectPairPotential(
 				ProfitMargin:  	profit,
 				BuyPrice: 	   	math.Round(bid.Price*100) / 100,
 				SellPrice: 	   	math.Round(ask.Price*100) / 100,
+				BuyPair:       	bid.Pair,
+				SellPair:      	ask.Pair,
 				BuyAmount:     	bid.Amount,
 				SuggestedBid:  	bid.Price + 0.01,
 				CreatedAt:     	time.Now(),
@@ -178,330 +178,90 @@ func DetectPairPotential(
 }
 
 
+// DetectAS takes the current order books and looks for arbitrage and potential situations.
 func DetectAS(minDiff, maxSum float64, chatID int64) ([]*domain.Opportunity, []*domain.Opportunity, error) {
-	CleanUpRecentHashes()
+	return DetectASFromBooks(CurrentBooks(), minDiff, maxSum)
+}
+
+// DetectASFromBooks runs the arbitrage and potential detectors over every
+// (ask book, bid book) combination of the given books.
+func DetectASFromBooks(books []*domain.OrderBook, minDiff, maxSum float64) ([]*domain.Opportunity, []*domain.Opportunity, error) {
 	opportunities := []*domain.Opportunity{}
 	potential := []*domain.Opportunity{}
+	fees := Fees()
 
-	rapiraRed, rapiraGreen,
-		GrinexUSDTA7A5Red, GrinexUSDTA7A5Green := getParsedData()
-
-	if len(rapiraRed) != 0 && len(rapiraGreen) != 0 {
-		opportunityRapiraRG, err := DetectPairArbitrage(rapiraRed[1:], rapiraGreen[1:], minDiff, maxSum, 0.0, 0.0, domain.RapiraSource, domain.RapiraSource, domain.Usdtrub)
-		if err != nil {
-			logger.Log.Errorf("failed to detect AS: %v", err)
-		}
-
-		opportunities = appendLastNonEmpty(opportunities, opportunityRapiraRG)
-	}
-
-	// if len(GrinexUSDTRUBRed) != 0 && len(GrinexUSDTRUBGreen) != 0 {
-	// 	opportunityGrinexUSDTRUB, err := DetectPairArbitrage(GrinexUSDTRUBRed[1:], GrinexUSDTRUBGreen[1:], minDiff, maxSum, 0.001, 0.001, domain.GrinexUSDTRUBSource, domain.GrinexUSDTRUBSource, domain.Usdtrub)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect AS: %v", err)
-	// 	}
-
-	// 	opportunities = appendLastNonEmpty(opportunities, opportunityGrinexUSDTRUB)
-	// }
-	
-	if len(GrinexUSDTA7A5Red) != 0 && len(GrinexUSDTA7A5Green) != 0 {
-		opportunityGrinexUSDTA7A5, err := DetectPairArbitrage(GrinexUSDTA7A5Red[1:], GrinexUSDTA7A5Green[1:], minDiff, maxSum, 0.0005, 0.0005, domain.GrinexUSDTA7A5Source, domain.GrinexUSDTA7A5Source, domain.Usdta7a5)
-		if err != nil {
-			logger.Log.Errorf("failed to detect AS: %v", err)
-		}
-
-		opportunities = appendLastNonEmpty(opportunities, opportunityGrinexUSDTA7A5)
-	}
-
-	// if len(rapiraRed) != 0 && len(GrinexUSDTRUBGreen) != 0 {
-	// 	opportunityRapiraAskGrinexUSDTRUBBid, err := DetectPairArbitrage(rapiraRed[1:], GrinexUSDTRUBGreen[1:], minDiff, maxSum, 0.0, 0.001, domain.RapiraSource, domain.GrinexUSDTRUBSource, domain.Usdtrub)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect AS: %v", err)
-	// 	}
-
-	// 	opportunities = appendLastNonEmpty(opportunities, opportunityRapiraAskGrinexUSDTRUBBid)
-	// }
-
-	// if len(GrinexUSDTRUBRed) != 0 && len(rapiraGreen) != 0 {
-	// 	opportunityGrinexUSDTRUBAskRapiraBid, err := DetectPairArbitrage(GrinexUSDTRUBRed[1:], rapiraGreen[1:], minDiff, maxSum, 0.001, 0.0, domain.GrinexUSDTRUBSource, domain.RapiraSource, domain.Usdtrub)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect AS: %v", err)
-	// 	}
-
-	// 	opportunities = appendLastNonEmpty(opportunities, opportunityGrinexUSDTRUBAskRapiraBid)
-	// }
-
-	if len(GrinexUSDTA7A5Red) != 0 && len(rapiraGreen) != 0 {
-		opportunityGrinexUSDTA7A5AskRapiraBid, err := DetectPairArbitrage(GrinexUSDTA7A5Red[1:], rapiraGreen[1:], minDiff, maxSum, 0.0005, 0.0, domain.GrinexUSDTA7A5Source, domain.RapiraSource, domain.Usdta7a5)
-		if err != nil {
-			logger.Log.Errorf("failed to detect AS: %v", err)
+	matrix := pairMatrix(books)
+	for _, bp := range matrix {
+		if len(bp.ask.Asks) == 0 || len(bp.bid.Bids) == 0 {
+			continue
 		}
-
-		opportunities = appendLastNonEmpty(opportunities, opportunityGrinexUSDTA7A5AskRapiraBid)
-	}
-
-	if len(rapiraRed) != 0 && len(GrinexUSDTA7A5Green) != 0 {
-		opportunityRapiraAskGrinexUSDTA7A5Bid, err := DetectPairArbitrage(rapiraRed[1:], GrinexUSDTA7A5Green[1:], minDiff, maxSum, 0.0, 0.0005, domain.RapiraSource, domain.GrinexUSDTA7A5Source, domain.Usdta7a5)
+		opps, err := DetectPairArbitrage(bp.ask.Asks[1:], bp.bid.Bids[1:], minDiff, maxSum,
+			fees, bp.ask.Source, bp.bid.Source, bp.ask.Pair)
 		if err != nil {
 			logger.Log.Errorf("failed to detect AS: %v", err)
 		}
-		
-		opportunities = appendLastNonEmpty(opportunities, opportunityRapiraAskGrinexUSDTA7A5Bid)
-	}
-
-	// if len(GrinexUSDTRUBRed) != 0 && len(GrinexUSDTA7A5Green) != 0 {
-	// 	opportunityGrinexUSDTRUBAskGrinexUSDTA7A5Bid, err := DetectPairArbitrage(GrinexUSDTRUBRed[1:], GrinexUSDTA7A5Green[1:], minDiff, maxSum, 0.001, 0.0005, domain.GrinexUSDTRUBSource, domain.GrinexUSDTA7A5Source, domain.Usdta7a5)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect AS: %v", err)
-	// 	}
-		
-	// 	opportunities = appendLastNonEmpty(opportunities, opportunityGrinexUSDTRUBAskGrinexUSDTA7A5Bid)
-	// }
-
-	// if len(GrinexUSDTA7A5Red) != 0 && len(GrinexUSDTRUBGreen) != 0 {
-	// 	opportunityGrinexUSDTA7A5AskGrinexUSDTRUBBid, err := DetectPairArbitrage(GrinexUSDTA7A5Red[1:], GrinexUSDTRUBGreen[1:], minDiff, maxSum, 0.0005, 0.001, domain.GrinexUSDTA7A5Source, domain.GrinexUSDTRUBSource, domain.Usdta7a5)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect AS: %v", err)
-	// 	}
-
-	// 	opportunities = appendLastNonEmpty(opportunities, opportunityGrinexUSDTA7A5AskGrinexUSDTRUBBid)
-	// }
-
-
-	if len(rapiraRed) != 0 && len(rapiraGreen) != 0 {
-		oppPotentialRap, err := DetectPairPotential(rapiraRed[1:], rapiraGreen[1:], minDiff, maxSum, 0.0, 0.0, domain.RapiraSource, domain.RapiraSource, domain.Usdtrub)
-		if err != nil {
-			logger.Log.Errorf("failed to detect potential situation: %v", err)
-		}
-
-		potential = appendLastNonEmpty(potential, oppPotentialRap)
+		opportunities = appendLastNonEmpty(opportunities, opps)
 	}
 
-	// if len(GrinexUSDTRUBRed) != 0 && len(GrinexUSDTRUBGreen) != 0 {
-	// 	oppPotentialGrUSDTRUB, err := DetectPairPotential(GrinexUSDTRUBRed[1:], GrinexUSDTRUBGreen[1:], minDiff, maxSum, 0.001, 0.001, domain.GrinexUSDTRUBSource, domain.GrinexUSDTRUBSource, domain.Usdtrub)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect potential situation: %v", err)
-	// 	}
-
-	// 	potential = appendLastNonEmpty(potential, oppPotentialGrUSDTRUB)
-	// }
-
-	if len(GrinexUSDTA7A5Red) != 0 && len(GrinexUSDTA7A5Green) != 0 {
-		oppPotentialGrUsdtA7a5, err := DetectPairPotential(GrinexUSDTA7A5Red[1:], GrinexUSDTA7A5Green[1:], minDiff, maxSum, 0.0005, 0.0005, domain.GrinexUSDTA7A5Source, domain.GrinexUSDTA7A5Source, domain.Usdta7a5)
-		if err != nil {
-			logger.Log.Errorf("failed to detect potential situation: %v", err)
-		}
-
-		potential = appendLastNonEmpty(potential, oppPotentialGrUsdtA7a5)
-	}
-
-	// if len(rapiraRed) != 0 && len(GrinexUSDTRUBGreen) != 0 {
-	// 	oppPotentialRapGRusdtRub, err := DetectPairPotential(rapiraRed[1:], GrinexUSDTRUBGreen[1:], minDiff, maxSum, 0.0, 0.001, domain.RapiraSource, domain.GrinexUSDTRUBSource, domain.Usdtrub)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect potential situation: %v", err)
-	// 	}
-
-	// 	potential = appendLastNonEmpty(potential, oppPotentialRapGRusdtRub)
-	// }
-
-	// if len(GrinexUSDTRUBRed) != 0 && len(rapiraGreen) != 0 {
-	// 	oppPotentialGrusdtRubRap, err := DetectPairPotential(GrinexUSDTRUBRed[1:], rapiraGreen[1:], minDiff, maxSum, 0.001, 0.0, domain.GrinexUSDTRUBSource, domain.RapiraSource, domain.Usdtrub)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect potential situation: %v", err)
-	// 	}
-
-	// 	potential = appendLastNonEmpty(potential, oppPotentialGrusdtRubRap)
-	// }
-
-	if len(rapiraRed) != 0 && len(GrinexUSDTA7A5Green) != 0 {
-		oppPotentialRapGrUsdtA7A5, err := DetectPairPotential(rapiraRed[1:], GrinexUSDTA7A5Green[1:], minDiff, maxSum, 0.0, 0.0005, domain.RapiraSource, domain.GrinexUSDTA7A5Source, domain.Usdtrub)
-		if err != nil {
-			logger.Log.Errorf("failed to detect potential situation: %v", err)
+	for _, bp := range matrix {
+		if len(bp.ask.Asks) == 0 || len(bp.bid.Bids) == 0 {
+			continue
 		}
-
-		potential = appendLastNonEmpty(potential, oppPotentialRapGrUsdtA7A5)
-	}
-
-	if len(GrinexUSDTA7A5Red) != 0 && len(rapiraGreen) != 0 {
-		oppPotentialGrUsdtA7a5Rap, err := DetectPairPotential(GrinexUSDTA7A5Red[1:], rapiraGreen[1:], minDiff, maxSum, 0.0005, 0.0, domain.GrinexUSDTA7A5Source, domain.RapiraSource, domain.Usdtrub)
+		pots, err := DetectPairPotential(bp.ask.Asks[1:], bp.bid.Bids[1:], minDiff, maxSum,
+			fees, bp.ask.Source, bp.bid.Source, bp.ask.Pair)
 		if err != nil {
 			logger.Log.Errorf("failed to detect potential situation: %v", err)
 		}
-
-		potential = appendLastNonEmpty(potential, oppPotentialGrUsdtA7a5Rap)
+		potential = appendLastNonEmpty(potential, pots)
 	}
 
-	// if len(GrinexUSDTRUBRed) != 0 && len(GrinexUSDTA7A5Green) != 0 {
-	// 	oppPotentialGrinexUsdtRubGrA7a5, err := DetectPairPotential(GrinexUSDTRUBRed[1:], GrinexUSDTA7A5Green[1:], minDiff, maxSum, 0.001, 0.0005, domain.GrinexUSDTRUBSource, domain.GrinexUSDTA7A5Source, domain.Usdtrub)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect potential situation: %v", err)
-	// 	}
-
-	// 	potential = appendLastNonEmpty(potential, oppPotentialGrinexUsdtRubGrA7a5)
-	// }
-
-	// if len(GrinexUSDTA7A5Red) != 0 && len(GrinexUSDTRUBGreen) != 0 {
-	// 	oppPotentialGrinexA7a5GrUsdtRub, err := DetectPairPotential(GrinexUSDTA7A5Red[1:], GrinexUSDTRUBGreen[1:], minDiff, maxSum, 0.0005, 0.001, domain.GrinexUSDTA7A5Source, domain.GrinexUSDTRUBSource, domain.Usdtrub)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect potential situation: %v", err)
-	// 	}
-
-	// 	potential = appendLastNonEmpty(potential, oppPotentialGrinexA7a5GrUsdtRub)
-	// }
-
 	if len(opportunities) == 0 && len(potential) == 0 {
 		logger.Log.Warn("empty orderbook; skip tick")
 		return nil, nil, nil
 	}
 
-
-	if len(potential) > 0 || len(opportunities) > 0{
-		logger.Log.Info("Arbitrage situation detected:\n")
-		for _, el := range opportunities {
-			logger.Log.Infof("Buy exchange: %v\tSell exchange: %v\nBuy price: %v\tSell price: %v\nBuy amount: %v\tProfit margin: %v\n Full profit %v\n",
-				el.BuyExchange, el.SellExchange, el.BuyPrice, el.SellPrice, el.BuyAmount, el.ProfitMargin, el.BuyAmount * (1 + el.ProfitMargin))
-		}	
-		return opportunities, potential, nil
-	} else {
-		logger.Log.Info("Arbitrage situation not detected")
-		return nil, nil, nil
+	logger.Log.Info("Arbitrage situation detected:\n")
+	for _, el := range opportunities {
+		logger.Log.Infof("Buy exchange: %v\tSell exchange: %v\nBuy price: %v\tSell price: %v\nBuy amount: %v\tProfit margin: %v\n Full profit %v\n",
+			el.BuyExchange, el.SellExchange, el.BuyPrice, el.SellPrice, el.BuyAmount, el.ProfitMargin, el.BuyAmount * (1 + el.ProfitMargin))
 	}
+	return opportunities, potential, nil
 }
 
+// DetectFact takes the current order books and looks for factual arbitrage.
 func DetectFact(minDiff, maxSum float64, chatID int64) ([]*domain.Opportunity, error) {
-	facticOpp := []*domain.Opportunity{}
-	
-	rapiraRed, rapiraGreen,
-	GrinexUSDTA7A5Red, GrinexUSDTA7A5Green := getParsedData()
 	logger.Log.Info("Getting facts")
+	return DetectFactFromBooks(CurrentBooks(), minDiff, maxSum)
+}
 
-	if len(rapiraRed) != 0 && len(rapiraGreen) != 0 {
-		factRapiraRG, err := DetectFactArbitrage(rapiraRed[0], rapiraGreen[0], minDiff, maxSum, 0.0, 0.0, domain.RapiraSource, domain.RapiraSource, domain.Usdtrub)
-		if err != nil {
-			logger.Log.Errorf("failed to detect AS: %v", err)
-		}
-
-		logger.Log.Info("Appending factic")
-		facticOpp = appendLastNonEmpty(facticOpp, factRapiraRG)
-	}
-
-	// if len(GrinexUSDTRUBRed) != 0 && len(GrinexUSDTRUBGreen) != 0 {
-	// 	factGrinexUSDTRUB, err := DetectFactArbitrage(GrinexUSDTRUBRed[0], GrinexUSDTRUBGreen[0], minDiff, maxSum, 0.001, 0.001, domain.GrinexUSDTRUBSource, domain.GrinexUSDTRUBSource, domain.Usdtrub)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect AS: %v", err)
-	// 	}
-
-	// 	logger.Log.Info("Appending factic")
-	// 	facticOpp = appendLastNonEmpty(facticOpp, factGrinexUSDTRUB)
-	// }
-
-	if len(GrinexUSDTA7A5Red) != 0 && len(GrinexUSDTA7A5Green) != 0 {
-		factGrinexUSDTA7A5, err := DetectFactArbitrage(GrinexUSDTA7A5Red[0], GrinexUSDTA7A5Green[0], minDiff, maxSum, 0.0005, 0.0005, domain.GrinexUSDTA7A5Source, domain.GrinexUSDTA7A5Source, domain.Usdta7a5)
-		if err != nil {
-			logger.Log.Errorf("failed to detect AS: %v", err)
-		}
-
-		facticOpp = appendLastNonEmpty(facticOpp, factGrinexUSDTA7A5)
-	}
-
-	// if len(rapiraRed) != 0 && len(GrinexUSDTRUBGreen) != 0 {
-	// 	factRapiraAskGrinexUSDTRUBBid, err := DetectFactArbitrage(rapiraRed[0], GrinexUSDTRUBGreen[0], minDiff, maxSum, 0.0, 0.001, domain.RapiraSource, domain.GrinexUSDTRUBSource, domain.Usdtrub)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect AS: %v", err)
-	// 	}
-
-	// 	logger.Log.Info("Appending factic")
-	// 	facticOpp = appendLastNonEmpty(facticOpp, factRapiraAskGrinexUSDTRUBBid)
-	// }
-
-	// if len(GrinexUSDTRUBRed) != 0 && len(rapiraRed) != 0 {
-	// 	factGrinexUSDTRUBAskRapiraBid, err := DetectFactArbitrage(GrinexUSDTRUBRed[0], rapiraGreen[0], minDiff, maxSum, 0.001, 0.0, domain.GrinexUSDTRUBSource, domain.RapiraSource, domain.Usdtrub)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect AS: %v", err)
-	// 	}
-
-	// 	logger.Log.Info("Appending factic")
-	// 	facticOpp = appendLastNonEmpty(facticOpp, factGrinexUSDTRUBAskRapiraBid)
-	// }
+// DetectFactFromBooks compares the best levels of every (ask book, bid book) combination.
+func DetectFactFromBooks(books []*domain.OrderBook, minDiff, maxSum float64) ([]*domain.Opportunity, error) {
+	facticOpp := []*domain.Opportunity{}
+	fees := Fees()
 
-	if len(GrinexUSDTA7A5Red) != 0 && len(rapiraGreen) != 0 {
-	factGrinexUSDTA7A5AskRapiraBid, err := DetectFactArbitrage(GrinexUSDTA7A5Red[0], rapiraGreen[0], minDiff, maxSum, 0.0005, 0.0, domain.GrinexUSDTA7A5Source, domain.RapiraSource, domain.Usdta7a5)
-		if err != nil {
-			logger.Log.Errorf("failed to detect AS: %v", err)
+	for _, bp := range pairMatrix(books) {
+		if len(bp.ask.Asks) == 0 || len(bp.bid.Bids) == 0 {
+			continue
 		}
-
-		logger.Log.Info("Appending factic")
-		facticOpp = appendLastNonEmpty(facticOpp, factGrinexUSDTA7A5AskRapiraBid)
-	}
-
-	if len(rapiraRed) != 0 && len(GrinexUSDTA7A5Green) != 0 {
-	factRapiraAskGrinexUSDTA7A5Bid, err := DetectFactArbitrage(rapiraRed[0], GrinexUSDTA7A5Green[0], minDiff, maxSum, 0.0, 0.0005, domain.RapiraSource, domain.GrinexUSDTA7A5Source, domain.Usdta7a5)
+		facts, err := DetectFactArbitrage(bp.ask.Asks[0], bp.bid.Bids[0], minDiff, maxSum,
+			fees, bp.ask.Source, bp.bid.Source, bp.ask.Pair)
 		if err != nil {
 			logger.Log.Errorf("failed to detect AS: %v", err)
 		}
-		
-		logger.Log.Info("Appending factic")
-		facticOpp = appendLastNonEmpty(facticOpp, factRapiraAskGrinexUSDTA7A5Bid)
+		facticOpp = appendLastNonEmpty(facticOpp, facts)
 	}
 
-	// if len(GrinexUSDTRUBRed) != 0 && len(GrinexUSDTA7A5Green) != 0 {
-	// 	factGrinexUSDTRUBAskGrinexUSDTA7A5Bid, err := DetectFactArbitrage(GrinexUSDTRUBRed[0], GrinexUSDTA7A5Green[0], minDiff, maxSum, 0.001, 0.0005, domain.GrinexUSDTRUBSource, domain.GrinexUSDTA7A5Source, domain.Usdta7a5)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect AS: %v", err)
-	// 	}
-		
-	// 	logger.Log.Info("Appending factic")
-	// 	facticOpp = appendLastNonEmpty(facticOpp, factGrinexUSDTRUBAskGrinexUSDTA7A5Bid)
-	// }
-
-	// if len(GrinexUSDTA7A5Red) != 0 && len(GrinexUSDTRUBGreen) != 0 {
-	// 	factGrinexUSDTA7A5AskGrinexUSDTRUBBid, err := DetectFactArbitrage(GrinexUSDTA7A5Red[0], GrinexUSDTRUBGreen[0], minDiff, maxSum, 0.0005, 0.001, domain.GrinexUSDTA7A5Source, domain.GrinexUSDTRUBSource, domain.Usdta7a5)
-	// 	if err != nil {
-	// 		logger.Log.Errorf("failed to detect AS: %v", err)
-	// 	}
-
-	// 	logger.Log.Info("Appending factic")
-	// 	facticOpp = appendLastNonEmpty(facticOpp, factGrinexUSDTA7A5AskGrinexUSDTRUBBid)
-	// }	
-
-	if len(facticOpp) == 0{
+	if len(facticOpp) == 0 {
 		logger.Log.Warn("empty orderbook; skip tick")
 		return nil, nil
 	}
 
-	if len(facticOpp) > 0 {
-		logger.Log.Info("Arbitrage situation detected:\n")
-		for _, el := range facticOpp {
-			logger.Log.Infof("Buy exchange: %v\tSell exchange: %v\nBuy price: %v\tSell price: %v\nBuy amount: %v\tProfit margin: %v\n Full profit %v\n",
-				el.BuyExchange, el.SellExchange, el.BuyPrice, el.SellPrice, el.BuyAmount, el.ProfitMargin, el.BuyAmount * (1 + el.ProfitMargin))
-		}	
-		return facticOpp, nil
-	} else {
-		logger.Log.Info("Arbitrage situation not detected")
-		return nil, nil
+	logger.Log.Info("Arbitrage situation detected:\n")
+	for _, el := range facticOpp {
+		logger.Log.Infof("Buy exchange: %v\tSell exchange: %v\nBuy price: %v\tSell price: %v\nBuy amount: %v\tProfit margin: %v\n Full profit %v\n",
+			el.BuyExchange, el.SellExchange, el.BuyPrice, el.SellPrice, el.BuyAmount, el.ProfitMargin, el.BuyAmount * (1 + el.ProfitMargin))
 	}
-
-}
-
-func HashOpportunity(op *domain.Opportunity, chatID int64) string {
-	return fmt.Sprintf("%d-%s-%s-%f-%f-%f", chatID, op.BuyExchange, op.SellExchange, op.BuyPrice, op.SellPrice, op.BuyAmount)
-}
-
-func CleanUpRecentHashes() {
-	now := time.Now()
-	recentMu.Lock()
-	for hash, t := range RecentHashes {
-		if now.Sub(t) > time.Hour {
-			delete(RecentHashes, hash)
-		}
-	}
-	recentMu.Unlock()
-}
-
-func markAsSeen(hash string) {
-	recentMu.Lock()
-	RecentHashes[hash] = time.Now()
-	recentMu.Unlock()
+	return facticOpp, nil
 }
 
 func appendLastNonEmpty(dst []*domain.Opportunity, src []*domain.Opportunity) []*domain.Opportunity {
@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+)
+
+// VenueFee is the trading commission of one source for one pair.
+// An empty Pair applies to every pair of the source.
+type VenueFee struct {
+	Source domain.Source `json:"source"`
+	Pair   domain.Pair   `json:"pair,omitempty"`
+	Maker  float64       `json:"maker"`
+	Taker  float64       `json:"taker"`
+}
+
+// TransferFee is a fixed cost in RUB of moving funds from one source to another.
+type TransferFee struct {
+	From  domain.Source `json:"from"`
+	To    domain.Source `json:"to"`
+	Fixed float64       `json:"fixed"`
+}
+
+type FeeSchedule struct {
+	Venues    []VenueFee    `json:"venues"`
+	Transfers []TransferFee `json:"transfers"`
+}
+
+var (
+	feesMu      sync.RWMutex
+	currentFees = DefaultFeeSchedule()
+)
+
+// DefaultFeeSchedule returns the commissions used before the schedule became configurable.
+func DefaultFeeSchedule() *FeeSchedule {
+	return &FeeSchedule{
+		Venues: []VenueFee{
+			{Source: domain.RapiraSource, Maker: 0.0, Taker: 0.0},
+			{Source: domain.GrinexUSDTRUBSource, Maker: 0.001, Taker: 0.001},
+			{Source: domain.GrinexUSDTA7A5Source, Maker: 0.0005, Taker: 0.0005},
+			{Source: domain.GrinexA7A5RUBSource, Maker: 0.001, Taker: 0.001},
+		},
+	}
+}
+
+// LoadFeeSchedule reads a JSON fee schedule from path.
+func LoadFeeSchedule(path string) (*FeeSchedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fs FeeSchedule
+	if err := json.Unmarshal(data, &fs); err != nil {
+		return nil, fmt.Errorf("failed to parse fee schedule %s: %w", path, err)
+	}
+	if err := fs.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid fee schedule %s: %w", path, err)
+	}
+	return &fs, nil
+}
+
+func (f *FeeSchedule) Validate() error {
+	for _, v := range f.Venues {
+		if v.Source == "" {
+			return fmt.Errorf("venue fee without source")
+		}
+		if v.Maker < 0 || v.Maker >= 1 || v.Taker < 0 || v.Taker >= 1 {
+			return fmt.Errorf("%s %s: rates must be in [0, 1)", v.Source, v.Pair)
+		}
+	}
+	for _, t := range f.Transfers {
+		if t.From == "" || t.To == "" {
+			return fmt.Errorf("transfer fee without source")
+		}
+		if t.Fixed < 0 {
+			return fmt.Errorf("%s -> %s: negative transfer fee", t.From, t.To)
+		}
+	}
+	return nil
+}
+
+// Venue returns the fee of source for pair, preferring an exact pair match
+// over a source-wide entry. Unknown sources are free.
+func (f *FeeSchedule) Venue(source domain.Source, pair domain.Pair) VenueFee {
+	var fallback *VenueFee
+	for i, v := range f.Venues {
+		if v.Source != source {
+			continue
+		}
+		if v.Pair == pair {
+			return v
+		}
+		if v.Pair == "" {
+			fallback = &f.Venues[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback
+	}
+	return VenueFee{Source: source, Pair: pair}
+}
+
+// Transfer returns the fixed cost of moving funds between two sources.
+func (f *FeeSchedule) Transfer(from, to domain.Source) float64 {
+	if from == to {
+		return 0
+	}
+	for _, t := range f.Transfers {
+		if t.From == from && t.To == to {
+			return t.Fixed
+		}
+	}
+	return 0
+}
+
+// transferPerUnit spreads the transfer cost over amount.
+func (f *FeeSchedule) transferPerUnit(from, to domain.Source, amount float64) float64 {
+	fixed := f.Transfer(from, to)
+	if fixed == 0 || amount <= 0 {
+		return 0
+	}
+	return fixed / amount
+}
+
+func (f *FeeSchedule) String() string {
+	var b strings.Builder
+	b.WriteString("Комиссии площадок (maker / taker):\n")
+	for _, v := range f.Venues {
+		pair := string(v.Pair)
+		if pair == "" {
+			pair = "все пары"
+		}
+		fmt.Fprintf(&b, "• %s, %s: %.4f%% / %.4f%%\n", v.Source, pair, v.Maker*100, v.Taker*100)
+	}
+	if len(f.Transfers) == 0 {
+		b.WriteString("Переводы между площадками: без комиссии")
+		return b.String()
+	}
+	b.WriteString("Переводы между площадками:\n")
+	for _, t := range f.Transfers {
+		fmt.Fprintf(&b, "• %s → %s: %.2f RUB\n", t.From, t.To, t.Fixed)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func SetFeeSchedule(f *FeeSchedule) {
+	feesMu.Lock()
+	currentFees = f
+	feesMu.Unlock()
+}
+
+// Fees returns the fee schedule currently used by the detectors.
+func Fees() *FeeSchedule {
+	feesMu.RLock()
+	defer feesMu.RUnlock()
+	return currentFees
+}
@@ -1,106 +1,42 @@
 package usecase
 
 import (
+	"time"
+
 	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
 	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/cache"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/marketdata"
 	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/parser"
 	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
 )
 
-func getParsedData() (
-	[]*domain.Order, []*domain.Order,
-	[]*domain.Order, []*domain.Order,
-//	[]*domain.Order, []*domain.Order,
-	){
-	rapiraRed, err := cache.GlobalOrderCache.GetOrFetch(
-		cache.OrderCacheKey{
-		Source: domain.RapiraSource,
-		Pair:   domain.Usdtrub,
-		Side:   domain.SideBuy,
-		}, func() ([]*domain.Order, error) {
-			a, err := parser.FetchRapiraAsk()
-			return a, err
-		},
-	)
-	if err != nil {
-		logger.Log.Errorf("failed to fetch Rapira ask table: %v", err)
-	}
-	logger.Log.Infof("Got rapira: %v", len(rapiraRed))
-	rapiraGreen, err := cache.GlobalOrderCache.GetOrFetch(
-		cache.OrderCacheKey{
-		Source: domain.RapiraSource,
-		Pair:   domain.Usdtrub,
-		Side:   domain.SideSell,
-		}, func() ([]*domain.Order, error) {
-			a, err := parser.FetchRapiraBid()
-			return a, err
-		},
-	)
-	if err != nil {
-		logger.Log.Errorf("failed to fetch Rapira bid table: %v", err)
-	}
-	logger.Log.Infof("Got rapira: %v", len(rapiraGreen))
-	// GrinexUSDTRUBRed, err := cache.GlobalOrderCache.GetOrFetch(
-	// 	cache.OrderCacheKey{
-	// 	Source: domain.GrinexUSDTRUBSource,
-	// 	Pair:   domain.Usdtrub,
-	// 	Side:   domain.SideBuy,
-	// 	}, func() ([]*domain.Order, error) {
-	// 		a, err := parser.FetchGrinexAskUSDTRub()
-	// 		return a, err
-	// 	},
-	// )
-	// if err != nil {
-	// 	logger.Log.Errorf("failed to fetch Grinex USDT/RUB ask table: %v", err)
-	// 	GrinexUSDTRUBRed = []*domain.Order{}
-	// }
-	// logger.Log.Infof("Got Grinex Ask USDT/RUB: %v", len(GrinexUSDTRUBRed))
-	// GrinexUSDTRUBGreen, err :=cache.GlobalOrderCache.GetOrFetch(
-	// 	cache.OrderCacheKey{
-	// 	Source: domain.GrinexUSDTRUBSource,
-	// 	Pair:   domain.Usdtrub,
-	// 	Side:   domain.SideSell,
-	// 	}, func() ([]*domain.Order, error) {
-	// 		a, err := parser.FetchGrinexBidUSDTRub()
-	// 		return a, err
-	// 	},
-	// )
-	// if err != nil {
-	// 	logger.Log.Errorf("failed to fetch Grinex USDT/RUB bid table: %v", err)
-	// 	GrinexUSDTRUBGreen = []*domain.Order{}
-	// }
-	// logger.Log.Infof("Got Grinex Bid USDT/RUB: %v", len(GrinexUSDTRUBGreen))
-	GrinexUSDTA7A5Red, err := cache.GlobalOrderCache.GetOrFetch(
-		cache.OrderCacheKey{
-		Source: domain.GrinexUSDTA7A5Source,
-		Pair:   domain.Usdta7a5,
-		Side:   domain.SideBuy,
-		}, func() ([]*domain.Order, error) {
-			a, err := parser.FetchGrinexAskUSDTA7A5()
-			return a, err
-		},
-	)
-	if err != nil {
-		logger.Log.Errorf("failed to fetch Grinex USDT/A7A5 ask table: %v", err)
-		GrinexUSDTA7A5Red = []*domain.Order{}
-	}
-	logger.Log.Infof("Got Grinex Ask USDT/A7A5: %v", len(GrinexUSDTA7A5Red))
-	GrinexUSDTA7A5Green, err := cache.GlobalOrderCache.GetOrFetch(
-		cache.OrderCacheKey{
-		Source: domain.GrinexUSDTA7A5Source,
-		Pair:   domain.Usdta7a5,
-		Side:   domain.SideSell,
-		}, func() ([]*domain.Order, error) {
-			a, err := parser.FetchGrinexBidUSDTA7A5()
-			return a, err
-		},
-	)
-	if err != nil {
-		logger.Log.Errorf("failed to fetch Grinex USDT/A7A5 bid table: %v", err)
-		GrinexUSDTA7A5Green = []*domain.Order{}
-	}
-	logger.Log.Infof("Got Grinex Bid USDT/A7A5: %v", len(GrinexUSDTA7A5Green))
+// CurrentBooks returns the order books of every enabled source and pair as
+// the market-data hub holds them now. It never fetches: keeping the books
+// fresh is the poller's job. Books with a side missing or older than the
+// cache TTL are skipped. Take it once per tick, so every detector sees the
+// same books.
+func CurrentBooks() []*domain.OrderBook {
+	sources := parser.DefaultRegistry.Enabled()
+	books := make([]*domain.OrderBook, 0, len(sources))
+	ttl := cache.GlobalOrderCache.TTL()
+	now := time.Now()
 
+	for _, src := range sources {
+		for _, pair := range src.Pairs() {
+			asks, askAt, okAsk := marketdata.DefaultHub.Book(cache.OrderCacheKey{Source: src.Name(), Pair: pair, Side: domain.SideBuy})
+			bids, bidAt, okBid := marketdata.DefaultHub.Book(cache.OrderCacheKey{Source: src.Name(), Pair: pair, Side: domain.SideSell})
+			if !okAsk || !okBid {
+				logger.Log.Debugf("no %s %s order book yet", src.Name(), pair)
+				continue
+			}
+			if now.Sub(askAt) >= ttl || now.Sub(bidAt) >= ttl {
+				logger.Log.Warnf("%s %s order book is stale (asks %v, bids %v old), skip",
+					src.Name(), pair, now.Sub(askAt).Round(time.Second), now.Sub(bidAt).Round(time.Second))
+				continue
+			}
+			books = append(books, &domain.OrderBook{Source: src.Name(), Pair: pair, Asks: asks, Bids: bids})
+		}
+	}
 
-	return rapiraRed, rapiraGreen, GrinexUSDTA7A5Red, GrinexUSDTA7A5Green
-}
\ No newline at end of file
+	return books
+}
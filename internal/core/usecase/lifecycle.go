@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+)
+
+// Observation is one opportunity found in a tick.
+type Observation struct {
+	Key          string
+	Type         domain.SignalType
+	BuyExchange  domain.Source
+	SellExchange domain.Source
+	BuyPair      domain.Pair
+	SellPair     domain.Pair
+	Margin       float64
+}
+
+// ObserveOpportunity makes the Observation of a pair opportunity.
+func ObserveOpportunity(typ domain.SignalType, op *domain.Opportunity) Observation {
+	return Observation{
+		Key:          SignalKey(typ, op),
+		Type:         typ,
+		BuyExchange:  op.BuyExchange,
+		SellExchange: op.SellExchange,
+		BuyPair:      op.BuyPair,
+		SellPair:     op.SellPair,
+		Margin:       op.ProfitMargin,
+	}
+}
+
+// ObserveTriangle makes the Observation of a triangle: its first and last legs.
+func ObserveTriangle(op *domain.TriangleOpportunity) Observation {
+	obs := Observation{
+		Key:    TriangleKey(op),
+		Type:   domain.SignalTriangle,
+		Margin: TriangleMargin(op),
+	}
+	if len(op.Legs) > 0 {
+		first, last := op.Legs[0], op.Legs[len(op.Legs)-1]
+		obs.BuyExchange, obs.BuyPair = first.Source, first.Pair
+		obs.SellExchange, obs.SellPair = last.Source, last.Pair
+	}
+	return obs
+}
+
+// LifecycleChange is an event of one lifecycle.
+type LifecycleChange struct {
+	Event     domain.LifecycleEvent
+	Lifecycle *domain.Lifecycle
+}
+
+// LifecycleTracker follows the opportunities of one chat across ticks. It is
+// not safe for concurrent use: a worker owns the tracker of its chat.
+type LifecycleTracker struct {
+	chatID int64
+	// maxGap — если сигнал не видели дольше, считаем, что он закрылся, когда
+	// его видели в последний раз (анализ стоял, воркер перезапускался)
+	maxGap time.Duration
+	open   map[string]*domain.Lifecycle
+}
+
+// NewLifecycleTracker continues the lifecycles left open by a previous worker.
+func NewLifecycleTracker(chatID int64, open []*domain.Lifecycle, maxGap time.Duration) *LifecycleTracker {
+	t := &LifecycleTracker{
+		chatID: chatID,
+		maxGap: maxGap,
+		open:   make(map[string]*domain.Lifecycle, len(open)),
+	}
+	for _, l := range open {
+		t.open[l.Key] = l
+	}
+	return t
+}
+
+// Observe takes every opportunity of type typ found at now and returns what
+// happened: new ones are opened, changed margins are improved or worsened,
+// open ones of typ that are gone are closed. Call it only when the detector
+// of typ ran, or its opportunities would be closed by mistake.
+func (t *LifecycleTracker) Observe(now time.Time, typ domain.SignalType, obs []Observation) []LifecycleChange {
+	var out []LifecycleChange
+	seen := make(map[string]bool, len(obs))
+
+	for _, o := range obs {
+		if seen[o.Key] {
+			continue
+		}
+		seen[o.Key] = true
+
+		l, ok := t.open[o.Key]
+		if ok && now.Sub(l.UpdatedAt) > t.maxGap {
+			l.ClosedAt = l.UpdatedAt
+			out = append(out, LifecycleChange{Event: domain.LifecycleClosed, Lifecycle: l})
+			ok = false
+		}
+		if !ok {
+			l = &domain.Lifecycle{
+				ChatID:       t.chatID,
+				Key:          o.Key,
+				Type:         o.Type,
+				BuyExchange:  o.BuyExchange,
+				SellExchange: o.SellExchange,
+				BuyPair:      o.BuyPair,
+				SellPair:     o.SellPair,
+				OpenMargin:   o.Margin,
+				PeakMargin:   o.Margin,
+				LastMargin:   o.Margin,
+				OpenedAt:     now,
+				UpdatedAt:    now,
+			}
+			t.open[o.Key] = l
+			out = append(out, LifecycleChange{Event: domain.LifecycleOpened, Lifecycle: l})
+			continue
+		}
+
+		prev := l.LastMargin
+		l.LastMargin = o.Margin
+		l.UpdatedAt = now
+		if o.Margin > l.PeakMargin {
+			l.PeakMargin = o.Margin
+		}
+		switch {
+		case o.Margin > prev:
+			out = append(out, LifecycleChange{Event: domain.LifecycleImproved, Lifecycle: l})
+		case o.Margin < prev:
+			out = append(out, LifecycleChange{Event: domain.LifecycleWorsened, Lifecycle: l})
+		}
+	}
+
+	for key, l := range t.open {
+		if l.Type != typ || seen[key] {
+			continue
+		}
+		l.ClosedAt = now
+		if now.Sub(l.UpdatedAt) > t.maxGap {
+			l.ClosedAt = l.UpdatedAt
+		}
+		delete(t.open, key)
+		out = append(out, LifecycleChange{Event: domain.LifecycleClosed, Lifecycle: l})
+	}
+	return out
+}
+
+// SetMaxGap changes how long an opportunity may go unseen and still be open.
+func (t *LifecycleTracker) SetMaxGap(d time.Duration) { t.maxGap = d }
+
+// Lifecycle returns the open lifecycle of the key, or nil.
+func (t *LifecycleTracker) Lifecycle(key string) *domain.Lifecycle { return t.open[key] }
+
+// Open returns the lifecycles still open.
+func (t *LifecycleTracker) Open() []*domain.Lifecycle {
+	out := make([]*domain.Lifecycle, 0, len(t.open))
+	for _, l := range t.open {
+		out = append(out, l)
+	}
+	return out
+}
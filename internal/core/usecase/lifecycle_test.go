@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+)
+
+func obs(key string, margin float64) Observation {
+	return Observation{Key: key, Type: domain.SignalFact, Margin: margin}
+}
+
+// observeStep is one Observe call at t0+at; want lists "event key" pairs.
+type observeStep struct {
+	at   time.Duration
+	typ  domain.SignalType
+	obs  []Observation
+	want []string
+}
+
+func TestLifecycleTrackerObserve(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []observeStep
+	}{
+		{
+			name: "open, improve, worsen, keep",
+			steps: []observeStep{
+				{0, domain.SignalFact, []Observation{obs("a", 1)}, []string{"opened a"}},
+				{10 * time.Second, domain.SignalFact, []Observation{obs("a", 2)}, []string{"improved a"}},
+				{20 * time.Second, domain.SignalFact, []Observation{obs("a", 1.5)}, []string{"worsened a"}},
+				{30 * time.Second, domain.SignalFact, []Observation{obs("a", 1.5)}, nil},
+			},
+		},
+		{
+			name: "gone closes, comes back reopens",
+			steps: []observeStep{
+				{0, domain.SignalFact, []Observation{obs("a", 1), obs("b", 1)}, []string{"opened a", "opened b"}},
+				{10 * time.Second, domain.SignalFact, []Observation{obs("b", 1)}, []string{"closed a"}},
+				{20 * time.Second, domain.SignalFact, []Observation{obs("a", 1), obs("b", 1)}, []string{"opened a"}},
+			},
+		},
+		{
+			name: "other types are left open",
+			steps: []observeStep{
+				{0, domain.SignalFact, []Observation{obs("a", 1)}, []string{"opened a"}},
+				{10 * time.Second, domain.SignalDepth, nil, nil},
+				{20 * time.Second, domain.SignalFact, nil, []string{"closed a"}},
+			},
+		},
+		{
+			name: "seen again after max gap closes and reopens",
+			steps: []observeStep{
+				{0, domain.SignalFact, []Observation{obs("a", 1)}, []string{"opened a"}},
+				{5 * time.Minute, domain.SignalFact, []Observation{obs("a", 2)}, []string{"closed a", "opened a"}},
+			},
+		},
+		{
+			name: "duplicate keys in one tick count once",
+			steps: []observeStep{
+				{0, domain.SignalFact, []Observation{obs("a", 1), obs("a", 3)}, []string{"opened a"}},
+			},
+		},
+	}
+
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewLifecycleTracker(1, nil, time.Minute)
+			for i, s := range tt.steps {
+				var got []string
+				for _, ch := range tr.Observe(t0.Add(s.at), s.typ, s.obs) {
+					got = append(got, fmt.Sprintf("%s %s", ch.Event, ch.Lifecycle.Key))
+				}
+				sort.Strings(got)
+				if !slices.Equal(got, s.want) {
+					t.Fatalf("step %d: events %v, want %v", i, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLifecycleTrackerMargins(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tr := NewLifecycleTracker(1, nil, time.Minute)
+	tr.Observe(t0, domain.SignalFact, []Observation{obs("a", 1)})
+	tr.Observe(t0.Add(10*time.Second), domain.SignalFact, []Observation{obs("a", 3)})
+	tr.Observe(t0.Add(20*time.Second), domain.SignalFact, []Observation{obs("a", 2)})
+
+	l := tr.Lifecycle("a")
+	if l.OpenMargin != 1 || l.PeakMargin != 3 || l.LastMargin != 2 {
+		t.Errorf("margins open/peak/last = %v/%v/%v, want 1/3/2", l.OpenMargin, l.PeakMargin, l.LastMargin)
+	}
+	if d := l.Duration(); d != 20*time.Second {
+		t.Errorf("duration = %v, want 20s", d)
+	}
+
+	ch := tr.Observe(t0.Add(30*time.Second), domain.SignalFact, nil)
+	if len(ch) != 1 || !ch[0].Lifecycle.ClosedAt.Equal(t0.Add(30*time.Second)) {
+		t.Fatalf("close = %+v, want closed at the tick it was gone", ch)
+	}
+	if tr.Lifecycle("a") != nil || len(tr.Open()) != 0 {
+		t.Error("closed lifecycle is still open")
+	}
+}
+
+func TestLifecycleTrackerClosesAtLastSeen(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	left := &domain.Lifecycle{
+		ChatID: 1, Key: "a", Type: domain.SignalFact,
+		OpenMargin: 1, PeakMargin: 1, LastMargin: 1,
+		OpenedAt: t0, UpdatedAt: t0.Add(30 * time.Second),
+	}
+
+	tests := []struct {
+		name       string
+		at         time.Duration
+		obs        []Observation
+		want       []string
+		wantClosed time.Time
+	}{
+		{"gone within max gap", 80 * time.Second, nil, []string{"closed a"}, t0.Add(80 * time.Second)},
+		{"gone past max gap", 10 * time.Minute, nil, []string{"closed a"}, t0.Add(30 * time.Second)},
+		{"back past max gap", 10 * time.Minute, []Observation{obs("a", 2)}, []string{"closed a", "opened a"}, t0.Add(30 * time.Second)},
+		{"back within max gap", 80 * time.Second, []Observation{obs("a", 2)}, []string{"improved a"}, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := *left
+			tr := NewLifecycleTracker(1, []*domain.Lifecycle{&l}, time.Minute)
+			var got []string
+			for _, ch := range tr.Observe(t0.Add(tt.at), domain.SignalFact, tt.obs) {
+				got = append(got, fmt.Sprintf("%s %s", ch.Event, ch.Lifecycle.Key))
+			}
+			sort.Strings(got)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("events %v, want %v", got, tt.want)
+			}
+			if !l.ClosedAt.Equal(tt.wantClosed) {
+				t.Errorf("closed at %v, want %v", l.ClosedAt, tt.wantClosed)
+			}
+		})
+	}
+}
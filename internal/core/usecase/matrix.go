@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+)
+
+// bookPair is one combination checked by the detectors: the red book of ask
+// and the green book of bid.
+type bookPair struct {
+	ask *domain.OrderBook
+	bid *domain.OrderBook
+}
+
+// pairMatrix returns every (ask book, bid book) combination of books quoted
+// in the same pair, including a book paired with itself. Books of different
+// pairs (e.g. USDT/RUB and USDT/A7A5) are not comparable directly and are
+// handled by the triangular detector instead.
+func pairMatrix(books []*domain.OrderBook) []bookPair {
+	out := make([]bookPair, 0, len(books)*len(books))
+	for _, ask := range books {
+		for _, bid := range books {
+			if ask.Pair != bid.Pair {
+				continue
+			}
+			out = append(out, bookPair{ask: ask, bid: bid})
+		}
+	}
+	return out
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+)
+
+// SignalKey identifies the same opportunity across ticks: the signal type
+// and the venues and pairs, not the prices, which move all the time.
+func SignalKey(typ domain.SignalType, op *domain.Opportunity) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s", typ, op.BuyExchange, op.BuyPair, op.SellExchange, op.SellPair)
+}
+
+// TriangleKey is SignalKey for a triangle: its legs in order.
+func TriangleKey(op *domain.TriangleOpportunity) string {
+	var b strings.Builder
+	b.WriteString(string(domain.SignalTriangle))
+	for _, l := range op.Legs {
+		fmt.Fprintf(&b, "|%s %s %s>%s", l.Source, l.Pair, l.From, l.To)
+	}
+	return b.String()
+}
+
+// TriangleMargin is the profit per USDT that went through the cycle, in the
+// units of Opportunity.ProfitMargin.
+func TriangleMargin(op *domain.TriangleOpportunity) float64 {
+	if op.Volume <= 0 {
+		return 0
+	}
+	return op.TotalProfit / op.Volume
+}
@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"math"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+)
+
+// DetectTriangleArbitrage checks both directions of the RUB → USDT → A7A5 → RUB
+// cycle over the best levels of the three books, paying taker fees on every leg
+// and transfer costs between sources. A cycle is reported when it earns at
+// least minDiff RUB per USDT passed through it. maxSum caps the RUB put in;
+// the best levels' amounts cap it as well.
+func DetectTriangleArbitrage(
+	usdtRub, usdtA7a5, a7a5Rub *domain.OrderBook,
+	minDiff, maxSum float64,
+	fees *FeeSchedule,
+) []*domain.TriangleOpportunity {
+	opps := []*domain.TriangleOpportunity{}
+	if opp := forwardCycle(usdtRub, usdtA7a5, a7a5Rub, minDiff, maxSum, fees); opp != nil {
+		opps = append(opps, opp)
+	}
+	if opp := reverseCycle(usdtRub, usdtA7a5, a7a5Rub, minDiff, maxSum, fees); opp != nil {
+		opps = append(opps, opp)
+	}
+	return opps
+}
+
+// forwardCycle: RUB → USDT (buy on USDT/RUB) → A7A5 (sell USDT on USDT/A7A5) → RUB (sell A7A5 on A7A5/RUB).
+func forwardCycle(usdtRub, usdtA7a5, a7a5Rub *domain.OrderBook, minDiff, maxSum float64, fees *FeeSchedule) *domain.TriangleOpportunity {
+	if len(usdtRub.Asks) == 0 || len(usdtA7a5.Bids) == 0 || len(a7a5Rub.Bids) == 0 {
+		return nil
+	}
+	buyUsdt, sellUsdt, sellA7a5 := usdtRub.Asks[0], usdtA7a5.Bids[0], a7a5Rub.Bids[0]
+	if buyUsdt.Price <= 0 || sellUsdt.Price <= 0 || sellA7a5.Price <= 0 {
+		return nil
+	}
+
+	start := capAmount(maxSum,
+		buyUsdt.Amount*buyUsdt.Price,
+		sellUsdt.Amount*buyUsdt.Price,
+		sellA7a5.Amount/sellUsdt.Price*buyUsdt.Price,
+	)
+	if start <= 0 {
+		return nil
+	}
+
+	usdt := start / buyUsdt.Price * (1 - fees.Venue(usdtRub.Source, usdtRub.Pair).Taker)
+	a7a5 := usdt * sellUsdt.Price * (1 - fees.Venue(usdtA7a5.Source, usdtA7a5.Pair).Taker)
+	end := a7a5 * sellA7a5.Price * (1 - fees.Venue(a7a5Rub.Source, a7a5Rub.Pair).Taker)
+
+	legs := []domain.CycleLeg{
+		{Source: usdtRub.Source, Pair: usdtRub.Pair, From: domain.RUB, To: domain.USDT, Price: buyUsdt.Price},
+		{Source: usdtA7a5.Source, Pair: usdtA7a5.Pair, From: domain.USDT, To: domain.A7A5, Price: sellUsdt.Price},
+		{Source: a7a5Rub.Source, Pair: a7a5Rub.Pair, From: domain.A7A5, To: domain.RUB, Price: sellA7a5.Price},
+	}
+	return cycleOpportunity(legs, start, end, usdt, minDiff, fees)
+}
+
+// reverseCycle: RUB → A7A5 (buy on A7A5/RUB) → USDT (buy on USDT/A7A5) → RUB (sell USDT on USDT/RUB).
+func reverseCycle(usdtRub, usdtA7a5, a7a5Rub *domain.OrderBook, minDiff, maxSum float64, fees *FeeSchedule) *domain.TriangleOpportunity {
+	if len(a7a5Rub.Asks) == 0 || len(usdtA7a5.Asks) == 0 || len(usdtRub.Bids) == 0 {
+		return nil
+	}
+	buyA7a5, buyUsdt, sellUsdt := a7a5Rub.Asks[0], usdtA7a5.Asks[0], usdtRub.Bids[0]
+	if buyA7a5.Price <= 0 || buyUsdt.Price <= 0 || sellUsdt.Price <= 0 {
+		return nil
+	}
+
+	start := capAmount(maxSum,
+		buyA7a5.Amount*buyA7a5.Price,
+		buyUsdt.Amount*buyUsdt.Price*buyA7a5.Price,
+		sellUsdt.Amount*buyUsdt.Price*buyA7a5.Price,
+	)
+	if start <= 0 {
+		return nil
+	}
+
+	a7a5 := start / buyA7a5.Price * (1 - fees.Venue(a7a5Rub.Source, a7a5Rub.Pair).Taker)
+	usdt := a7a5 / buyUsdt.Price * (1 - fees.Venue(usdtA7a5.Source, usdtA7a5.Pair).Taker)
+	end := usdt * sellUsdt.Price * (1 - fees.Venue(usdtRub.Source, usdtRub.Pair).Taker)
+
+	legs := []domain.CycleLeg{
+		{Source: a7a5Rub.Source, Pair: a7a5Rub.Pair, From: domain.RUB, To: domain.A7A5, Price: buyA7a5.Price},
+		{Source: usdtA7a5.Source, Pair: usdtA7a5.Pair, From: domain.A7A5, To: domain.USDT, Price: buyUsdt.Price},
+		{Source: usdtRub.Source, Pair: usdtRub.Pair, From: domain.USDT, To: domain.RUB, Price: sellUsdt.Price},
+	}
+	return cycleOpportunity(legs, start, end, usdt, minDiff, fees)
+}
+
+func cycleOpportunity(legs []domain.CycleLeg, start, end, usdt, minDiff float64, fees *FeeSchedule) *domain.TriangleOpportunity {
+	var transfers float64
+	for i := range legs {
+		next := legs[(i+1)%len(legs)]
+		transfers += fees.Transfer(legs[i].Source, next.Source)
+	}
+
+	profit := end - start - transfers
+	if profit <= 0 || usdt <= 0 || profit/usdt < minDiff {
+		return nil
+	}
+
+	opp := &domain.TriangleOpportunity{
+		Legs:          legs,
+		StartAmount:   math.Round(start*100) / 100,
+		EndAmount:     math.Round(end*100) / 100,
+		Volume:        usdt,
+		TotalProfit:   math.Round(profit*100) / 100,
+		ProfitPercent: math.Round(profit/start*100*1000) / 1000,
+		CreatedAt:     time.Now(),
+	}
+	logger.Log.Infof("Found triangle arbitrage: %s %s → %s → %s, Profit: %.2f RUB (%.3f%%)",
+		legs[0].From, legs[0].Source, legs[1].Source, legs[2].Source, opp.TotalProfit, opp.ProfitPercent)
+	return opp
+}
+
+// capAmount returns the smallest positive limit; maxSum <= 0 means no user limit.
+func capAmount(maxSum float64, limits ...float64) float64 {
+	out := math.Inf(1)
+	if maxSum > 0 {
+		out = maxSum
+	}
+	for _, l := range limits {
+		if l < out {
+			out = l
+		}
+	}
+	if math.IsInf(out, 1) {
+		return 0
+	}
+	return out
+}
+
+// DetectTriangle takes the current order books and looks for triangular cycles.
+func DetectTriangle(minDiff, maxSum float64, chatID int64) ([]*domain.TriangleOpportunity, error) {
+	return DetectTriangleFromBooks(CurrentBooks(), minDiff, maxSum)
+}
+
+// DetectTriangleFromBooks tries every combination of USDT/RUB, USDT/A7A5 and A7A5/RUB books.
+func DetectTriangleFromBooks(books []*domain.OrderBook, minDiff, maxSum float64) ([]*domain.TriangleOpportunity, error) {
+	byPair := make(map[domain.Pair][]*domain.OrderBook)
+	for _, b := range books {
+		byPair[b.Pair] = append(byPair[b.Pair], b)
+	}
+
+	fees := Fees()
+	opps := []*domain.TriangleOpportunity{}
+	for _, usdtRub := range byPair[domain.Usdtrub] {
+		for _, usdtA7a5 := range byPair[domain.Usdta7a5] {
+			for _, a7a5Rub := range byPair[domain.A7a5rub] {
+				opps = append(opps, DetectTriangleArbitrage(usdtRub, usdtA7a5, a7a5Rub, minDiff, maxSum, fees)...)
+			}
+		}
+	}
+
+	if len(opps) == 0 {
+		logger.Log.Info("No triangle arbitrage situation")
+	}
+	return opps, nil
+}
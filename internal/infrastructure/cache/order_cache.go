@@ -6,11 +6,16 @@ import (
 	"time"
 
 	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/metrics"
 	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
 )
 
 var GlobalOrderCache = NewOrderCache()
 
+func init() {
+	metrics.RegisterBookAges(GlobalOrderCache.ages)
+}
+
 type OrderCacheKey struct {
 	Source domain.Source
 	Pair   domain.Pair
@@ -22,23 +27,47 @@ func (k OrderCacheKey) String() string {
 }
 
 type cacheEntry struct {
+	Key        OrderCacheKey
 	Orders     []*domain.Order
 	UpdatedAt  time.Time
 	IsUpdating bool
 }
 
+// UpdateFunc is called after fresh orders are stored under key.
+type UpdateFunc func(key OrderCacheKey, orders []*domain.Order, at time.Time)
+
+// DefaultTTL is how long a cached book is served without refetching.
+const DefaultTTL = 60 * time.Second
+
 type OrderCache struct {
-	mu   sync.RWMutex
-	data map[string]*cacheEntry
+	mu        sync.RWMutex
+	data      map[string]*cacheEntry
+	observers []UpdateFunc
+	ttl       time.Duration
 }
 
 func NewOrderCache() *OrderCache {
 	return &OrderCache{
 		data: make(map[string]*cacheEntry),
 		mu:   sync.RWMutex{},
+		ttl:  DefaultTTL,
 	}
 }
 
+// SetTTL changes how long cached books stay fresh.
+func (c *OrderCache) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	c.ttl = ttl
+	c.mu.Unlock()
+}
+
+// TTL returns how long cached books stay fresh.
+func (c *OrderCache) TTL() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ttl
+}
+
 func (c *OrderCache) GetOrFetch(
 	key OrderCacheKey,
 	fetchFunc func() ([]*domain.Order, error),
@@ -48,12 +77,14 @@ func (c *OrderCache) GetOrFetch(
 	// Чтение из кэша
 	c.mu.RLock()
 	entry, exists := c.data[keyHash]
+	ttl := c.ttl
 	c.mu.RUnlock()
 
 	if exists {
 		// Если кэш свежий
-		if time.Since(entry.UpdatedAt) < 60*time.Second {
+		if time.Since(entry.UpdatedAt) < ttl {
 			logger.Log.Info("Got cache")
+			metrics.CacheRequests.WithLabelValues("hit").Inc()
 			return entry.Orders, nil
 		}
 
@@ -69,6 +100,7 @@ func (c *OrderCache) GetOrFetch(
 
 				if isDone {
 					logger.Log.Info("Waited for cache")
+					metrics.CacheRequests.WithLabelValues("wait").Inc()
 					return orders, nil
 				}
 			}
@@ -76,10 +108,11 @@ func (c *OrderCache) GetOrFetch(
 	}
 
 	// Обновление кэша
+	metrics.CacheRequests.WithLabelValues("miss").Inc()
 	c.mu.Lock()
 	entry, exists = c.data[keyHash]
 	if !exists {
-		entry = &cacheEntry{}
+		entry = &cacheEntry{Key: key}
 		c.data[keyHash] = entry
 	}
 	entry.IsUpdating = true
@@ -100,8 +133,63 @@ func (c *OrderCache) GetOrFetch(
 	entry.Orders = orders
 	entry.UpdatedAt = time.Now()
 	entry.IsUpdating = false
+	updatedAt := entry.UpdatedAt
 	c.mu.Unlock()
 
 	logger.Log.Info("Updated cache")
+	c.notify(key, orders, updatedAt)
 	return orders, nil
 }
+
+
+// Set stores orders under key as if they had just been fetched.
+func (c *OrderCache) Set(key OrderCacheKey, orders []*domain.Order) {
+	keyHash := key.String()
+
+	c.mu.Lock()
+	entry, exists := c.data[keyHash]
+	if !exists {
+		entry = &cacheEntry{Key: key}
+		c.data[keyHash] = entry
+	}
+	entry.Orders = orders
+	entry.UpdatedAt = time.Now()
+	updatedAt := entry.UpdatedAt
+	c.mu.Unlock()
+
+	c.notify(key, orders, updatedAt)
+}
+
+// OnUpdate registers fn to be called every time a book is stored in the cache.
+func (c *OrderCache) OnUpdate(fn UpdateFunc) {
+	c.mu.Lock()
+	c.observers = append(c.observers, fn)
+	c.mu.Unlock()
+}
+
+func (c *OrderCache) notify(key OrderCacheKey, orders []*domain.Order, at time.Time) {
+	c.mu.RLock()
+	observers := c.observers
+	c.mu.RUnlock()
+
+	for _, fn := range observers {
+		fn(key, orders, at)
+	}
+}
+
+// ages lists the update time of every cached book side for the metrics.
+func (c *OrderCache) ages() []metrics.BookAge {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := make([]metrics.BookAge, 0, len(c.data))
+	for _, e := range c.data {
+		out = append(out, metrics.BookAge{
+			Source:    string(e.Key.Source),
+			Pair:      string(e.Key.Pair),
+			Side:      string(e.Key.Side),
+			UpdatedAt: e.UpdatedAt,
+		})
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+)
+
+// LifecycleRepository keeps the lifecycles of the opportunities of every chat.
+type LifecycleRepository interface {
+	// OpenLifecycles returns the lifecycles of the chat that are not closed.
+	OpenLifecycles(chatID int64) ([]*domain.Lifecycle, error)
+	// SaveLifecycle inserts a new lifecycle or updates it by ID.
+	SaveLifecycle(l *domain.Lifecycle) error
+}
+
+func createLifecyclesTable(db *sql.DB) error {
+	createTable := `
+    CREATE TABLE IF NOT EXISTS opportunity_lifecycles (
+        id            INTEGER PRIMARY KEY AUTOINCREMENT,
+        chat_id       INTEGER NOT NULL,
+        signal_key    TEXT NOT NULL,
+        type          TEXT NOT NULL,
+        buy_exchange  TEXT NOT NULL,
+        sell_exchange TEXT NOT NULL,
+        buy_pair      TEXT NOT NULL,
+        sell_pair     TEXT NOT NULL,
+        open_margin   REAL NOT NULL,
+        peak_margin   REAL NOT NULL,
+        last_margin   REAL NOT NULL,
+        opened_at     INTEGER NOT NULL,
+        updated_at    INTEGER NOT NULL,
+        closed_at     INTEGER NOT NULL DEFAULT 0
+    );
+    CREATE INDEX IF NOT EXISTS idx_lifecycles_open ON opportunity_lifecycles (chat_id, closed_at);
+    `
+	if _, err := db.Exec(createTable); err != nil {
+		return fmt.Errorf("failed to create opportunity_lifecycles table: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteOpportunityStore) OpenLifecycles(chatID int64) ([]*domain.Lifecycle, error) {
+	rows, err := s.db.Query(`SELECT id, chat_id, signal_key, type, buy_exchange, sell_exchange, buy_pair, sell_pair,
+        open_margin, peak_margin, last_margin, opened_at, updated_at
+        FROM opportunity_lifecycles WHERE chat_id = ? AND closed_at = 0`, chatID)
+	if err != nil {
+		logger.Log.Errorf("failed to query lifecycles: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*domain.Lifecycle
+	for rows.Next() {
+		var (
+			l                   domain.Lifecycle
+			openedAt, updatedAt int64
+		)
+		if err := rows.Scan(&l.ID, &l.ChatID, &l.Key, &l.Type, &l.BuyExchange, &l.SellExchange, &l.BuyPair, &l.SellPair,
+			&l.OpenMargin, &l.PeakMargin, &l.LastMargin, &openedAt, &updatedAt); err != nil {
+			logger.Log.Errorf("failed to scan lifecycle row: %v", err)
+			return nil, err
+		}
+		l.OpenedAt = time.UnixMilli(openedAt)
+		l.UpdatedAt = time.UnixMilli(updatedAt)
+		out = append(out, &l)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteOpportunityStore) SaveLifecycle(l *domain.Lifecycle) error {
+	var closedAt int64
+	if !l.IsOpen() {
+		closedAt = l.ClosedAt.UnixMilli()
+	}
+
+	if l.ID != 0 {
+		_, err := s.db.Exec(`UPDATE opportunity_lifecycles SET peak_margin = ?, last_margin = ?, updated_at = ?, closed_at = ?
+            WHERE id = ?`, l.PeakMargin, l.LastMargin, l.UpdatedAt.UnixMilli(), closedAt, l.ID)
+		if err != nil {
+			logger.Log.Errorf("failed to update lifecycle %d: %v", l.ID, err)
+		}
+		return err
+	}
+
+	res, err := s.db.Exec(`INSERT INTO opportunity_lifecycles (chat_id, signal_key, type, buy_exchange, sell_exchange,
+        buy_pair, sell_pair, open_margin, peak_margin, last_margin, opened_at, updated_at, closed_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		l.ChatID, l.Key, l.Type, l.BuyExchange, l.SellExchange, l.BuyPair, l.SellPair,
+		l.OpenMargin, l.PeakMargin, l.LastMargin, l.OpenedAt.UnixMilli(), l.UpdatedAt.UnixMilli(), closedAt)
+	if err != nil {
+		logger.Log.Errorf("failed to insert lifecycle: %v", err)
+		return err
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		l.ID = id
+	}
+	return nil
+}
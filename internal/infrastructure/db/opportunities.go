@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+)
+
+// OpportunityRecord is one signal found by a worker.
+type OpportunityRecord struct {
+	ID           int64
+	ChatID       int64
+	Type         domain.SignalType
+	BuyExchange  domain.Source
+	SellExchange domain.Source
+	BuyPair      domain.Pair
+	SellPair     domain.Pair
+	BuyPrice     float64
+	SellPrice    float64
+	Amount       float64
+	Margin       float64
+	TotalProfit  float64
+	DetectedAt   time.Time
+	RecordedAt   time.Time
+}
+
+// OpportunityFilter narrows Query; zero fields are not applied.
+type OpportunityFilter struct {
+	ChatID       int64
+	Type         domain.SignalType
+	From         time.Time
+	To           time.Time
+	BuyExchange  domain.Source
+	SellExchange domain.Source
+	MinMargin    float64
+	Limit        int
+}
+
+type OpportunityRepository interface {
+	Save(rec *OpportunityRecord) error
+	Query(filter OpportunityFilter) ([]*OpportunityRecord, error)
+}
+
+type SQLiteOpportunityStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteOpportunityStore(dbPath string) (*SQLiteOpportunityStore, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	createTable := `
+    CREATE TABLE IF NOT EXISTS opportunities (
+        id            INTEGER PRIMARY KEY AUTOINCREMENT,
+        chat_id       INTEGER NOT NULL,
+        type          TEXT NOT NULL,
+        buy_exchange  TEXT NOT NULL,
+        sell_exchange TEXT NOT NULL,
+        buy_pair      TEXT NOT NULL,
+        sell_pair     TEXT NOT NULL,
+        buy_price     REAL NOT NULL,
+        sell_price    REAL NOT NULL,
+        amount        REAL NOT NULL,
+        margin        REAL NOT NULL,
+        total_profit  REAL NOT NULL,
+        detected_at   INTEGER NOT NULL,
+        recorded_at   INTEGER NOT NULL
+    );
+    CREATE INDEX IF NOT EXISTS idx_opportunities_chat_time ON opportunities (chat_id, detected_at);
+    CREATE INDEX IF NOT EXISTS idx_opportunities_venues ON opportunities (buy_exchange, sell_exchange, detected_at);
+    `
+	if _, err := db.Exec(createTable); err != nil {
+		return nil, fmt.Errorf("failed to create opportunities table: %w", err)
+	}
+	if err := createLifecyclesTable(db); err != nil {
+		return nil, err
+	}
+
+	return &SQLiteOpportunityStore{db: db}, nil
+}
+
+func (s *SQLiteOpportunityStore) Save(rec *OpportunityRecord) error {
+	if rec.RecordedAt.IsZero() {
+		rec.RecordedAt = time.Now()
+	}
+	if rec.DetectedAt.IsZero() {
+		rec.DetectedAt = rec.RecordedAt
+	}
+
+	query := `INSERT INTO opportunities (chat_id, type, buy_exchange, sell_exchange, buy_pair, sell_pair,
+        buy_price, sell_price, amount, margin, total_profit, detected_at, recorded_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := s.db.Exec(query, rec.ChatID, rec.Type, rec.BuyExchange, rec.SellExchange, rec.BuyPair, rec.SellPair,
+		rec.BuyPrice, rec.SellPrice, rec.Amount, rec.Margin, rec.TotalProfit,
+		rec.DetectedAt.UnixMilli(), rec.RecordedAt.UnixMilli())
+	if err != nil {
+		logger.Log.Errorf("failed to insert opportunity: %v", err)
+		return err
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		rec.ID = id
+	}
+	return nil
+}
+
+// Query returns matching records, newest first.
+func (s *SQLiteOpportunityStore) Query(f OpportunityFilter) ([]*OpportunityRecord, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if f.ChatID != 0 {
+		where = append(where, "chat_id = ?")
+		args = append(args, f.ChatID)
+	}
+	if f.Type != "" {
+		where = append(where, "type = ?")
+		args = append(args, f.Type)
+	}
+	if !f.From.IsZero() {
+		where = append(where, "detected_at >= ?")
+		args = append(args, f.From.UnixMilli())
+	}
+	if !f.To.IsZero() {
+		where = append(where, "detected_at < ?")
+		args = append(args, f.To.UnixMilli())
+	}
+	if f.BuyExchange != "" {
+		where = append(where, "buy_exchange = ?")
+		args = append(args, f.BuyExchange)
+	}
+	if f.SellExchange != "" {
+		where = append(where, "sell_exchange = ?")
+		args = append(args, f.SellExchange)
+	}
+	if f.MinMargin != 0 {
+		where = append(where, "margin >= ?")
+		args = append(args, f.MinMargin)
+	}
+
+	query := `SELECT id, chat_id, type, buy_exchange, sell_exchange, buy_pair, sell_pair,
+        buy_price, sell_price, amount, margin, total_profit, detected_at, recorded_at FROM opportunities`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY detected_at DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		logger.Log.Errorf("failed to query opportunities: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*OpportunityRecord
+	for rows.Next() {
+		var (
+			rec                    OpportunityRecord
+			detectedAt, recordedAt int64
+		)
+		if err := rows.Scan(&rec.ID, &rec.ChatID, &rec.Type, &rec.BuyExchange, &rec.SellExchange, &rec.BuyPair, &rec.SellPair,
+			&rec.BuyPrice, &rec.SellPrice, &rec.Amount, &rec.Margin, &rec.TotalProfit, &detectedAt, &recordedAt); err != nil {
+			logger.Log.Errorf("failed to scan opportunity row: %v", err)
+			return nil, err
+		}
+		rec.DetectedAt = time.UnixMilli(detectedAt)
+		rec.RecordedAt = time.UnixMilli(recordedAt)
+		out = append(out, &rec)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteOpportunityStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *SQLiteOpportunityStore) Close() error {
+	return s.db.Close()
+}
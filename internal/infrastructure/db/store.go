@@ -1,8 +1,11 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
 	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
@@ -37,13 +40,75 @@ func NewSQLiteUserStateStore(dbPath string) (*SQLiteUserStateStore, error) {
     if _, err := db.Exec(createTable); err != nil {
         return nil, fmt.Errorf("failed to create user_states table: %w", err)
     }
+    if err := migrateUserStates(db); err != nil {
+        return nil, fmt.Errorf("failed to migrate user_states table: %w", err)
+    }
 
     return &SQLiteUserStateStore{db: db}, nil
 }
 
+// scheduleColumns are the columns of domain.Schedule, added to tables
+// created before per-user schedules.
+var scheduleColumns = []struct{ name, def string }{
+    {"tick_interval_ms", "INTEGER NOT NULL DEFAULT 0"},
+    {"quiet_from", "INTEGER NOT NULL DEFAULT 0"},
+    {"quiet_to", "INTEGER NOT NULL DEFAULT 0"},
+    {"timezone", "TEXT NOT NULL DEFAULT ''"},
+    {"weekdays", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// filterColumns are the columns of domain.Filters: comma-separated lists.
+var filterColumns = []struct{ name, def string }{
+    {"disabled_sources", "TEXT NOT NULL DEFAULT ''"},
+    {"disabled_signals", "TEXT NOT NULL DEFAULT ''"},
+}
+
+func migrateUserStates(db *sql.DB) error {
+    rows, err := db.Query(`PRAGMA table_info(user_states)`)
+    if err != nil {
+        return err
+    }
+    have := make(map[string]bool)
+    for rows.Next() {
+        var (
+            cid     int
+            name    string
+            typ     string
+            notNull int
+            dflt    sql.NullString
+            pk      int
+        )
+        if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+            rows.Close()
+            return err
+        }
+        have[name] = true
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return err
+    }
+
+    for _, c := range append(scheduleColumns, filterColumns...) {
+        if have[c.name] {
+            continue
+        }
+        if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE user_states ADD COLUMN %s %s`, c.name, c.def)); err != nil {
+            return err
+        }
+        logger.Log.Infof("user_states: added column %s", c.name)
+    }
+    return nil
+}
+
 func (s *SQLiteUserStateStore) Set(chatID int64, state *domain.UserState) error {
-    query := `INSERT OR REPLACE INTO user_states (chat_id, min_diff, max_sum, step) VALUES (?, ?, ?, ?)`
-    if _, err := s.db.Exec(query, chatID, state.MinDiff, state.MaxSum, state.Step); err != nil {
+    query := `INSERT OR REPLACE INTO user_states
+        (chat_id, min_diff, max_sum, step, tick_interval_ms, quiet_from, quiet_to, timezone, weekdays,
+        disabled_sources, disabled_signals)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+    if _, err := s.db.Exec(query, chatID, state.MinDiff, state.MaxSum, state.Step,
+        state.Schedule.TickInterval.Milliseconds(), state.Schedule.QuietFrom, state.Schedule.QuietTo, state.Schedule.Timezone, state.Schedule.Weekdays,
+        joinList(state.Filters.DisabledSources), joinList(state.Filters.DisabledSignals)); err != nil {
     	logger.Log.Errorf("failed to exec DB: %v", err)
 		return err
 	}
@@ -52,20 +117,50 @@ func (s *SQLiteUserStateStore) Set(chatID int64, state *domain.UserState) error
 }
 
 func (s *SQLiteUserStateStore) Get(chatID int64) (*domain.UserState, error) {
-    query := `SELECT min_diff, max_sum, step FROM user_states WHERE chat_id = ?`
+    query := `SELECT min_diff, max_sum, step, tick_interval_ms, quiet_from, quiet_to, timezone, weekdays,
+        disabled_sources, disabled_signals
+        FROM user_states WHERE chat_id = ?`
     row:= s.db.QueryRow(query, chatID)
-	var state domain.UserState
-	if err := row.Scan(&state.MinDiff, &state.MaxSum, &state.Step); err != nil {
+	var (
+		state            domain.UserState
+		tickMs           int64
+		sources, signals string
+	)
+	if err := row.Scan(&state.MinDiff, &state.MaxSum, &state.Step,
+		&tickMs, &state.Schedule.QuietFrom, &state.Schedule.QuietTo, &state.Schedule.Timezone, &state.Schedule.Weekdays,
+		&sources, &signals); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		logger.Log.Errorf("failed to scan DB row: %v", err)
 		return nil, err
 	}
+	state.Schedule.TickInterval = time.Duration(tickMs) * time.Millisecond
+	state.Filters.DisabledSources = splitList[domain.Source](sources)
+	state.Filters.DisabledSignals = splitList[domain.SignalType](signals)
 
 	return &state, nil
 }
 
+func joinList[T ~string](items []T) string {
+	parts := make([]string, len(items))
+	for i, it := range items {
+		parts[i] = string(it)
+	}
+	return strings.Join(parts, ",")
+}
+
+func splitList[T ~string](s string) []T {
+	if s == "" {
+		return nil
+	}
+	var out []T
+	for _, p := range strings.Split(s, ",") {
+		out = append(out, T(p))
+	}
+	return out
+}
+
 func (s *SQLiteUserStateStore) Delete(chatID int64) error {
 	query := `DELETE FROM user_states WHERE chat_id = ?`
 	_, err := s.db.Exec(query, chatID)
@@ -74,3 +169,11 @@ func (s *SQLiteUserStateStore) Delete(chatID int64) error {
 	}
 	return err
 }
+
+func (s *SQLiteUserStateStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *SQLiteUserStateStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,191 @@
+// Package health runs named dependency checks in the background and serves
+// their last results on /healthz and /readyz.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+)
+
+type Status string
+
+const (
+	StatusUnknown Status = "unknown"
+	StatusOK      Status = "ok"
+	StatusFail    Status = "fail"
+)
+
+// Kind tells which endpoint a check affects: liveness checks fail /healthz
+// (the process should be restarted), every check fails /readyz.
+type Kind string
+
+const (
+	Liveness  Kind = "liveness"
+	Readiness Kind = "readiness"
+)
+
+// CheckFunc probes one dependency. Details are reported as is, next to the status.
+type CheckFunc func(ctx context.Context) (details any, err error)
+
+type Result struct {
+	Name        string    `json:"name"`
+	Kind        Kind      `json:"kind"`
+	Status      Status    `json:"status"`
+	Details     any       `json:"details,omitempty"`
+	CheckedAt   time.Time `json:"checked_at,omitzero"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitzero"`
+}
+
+type check struct {
+	fn     CheckFunc
+	result Result
+}
+
+type Checker struct {
+	mu      sync.RWMutex
+	checks  []*check
+	timeout time.Duration
+}
+
+var Default = NewChecker(10 * time.Second)
+
+// NewChecker creates a checker that gives each check at most timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Register(name string, kind Kind, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, &check{
+		fn:     fn,
+		result: Result{Name: name, Kind: kind, Status: StatusUnknown},
+	})
+}
+
+// Run checks everything right away and then every interval until ctx is done.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		c.CheckAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// CheckAll runs every check concurrently and stores the results.
+func (c *Checker) CheckAll(ctx context.Context) {
+	c.mu.RLock()
+	checks := make([]*check, len(c.checks))
+	copy(checks, c.checks)
+	c.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, ch := range checks {
+		wg.Add(1)
+		go func(ch *check) {
+			defer wg.Done()
+			c.runCheck(ctx, ch)
+		}(ch)
+	}
+	wg.Wait()
+}
+
+func (c *Checker) runCheck(ctx context.Context, ch *check) {
+	cctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	details, err := ch.fn(cctx)
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev := ch.result.Status
+	ch.result.Details = details
+	ch.result.CheckedAt = now
+	if err != nil {
+		ch.result.Status = StatusFail
+		ch.result.LastError = err.Error()
+		ch.result.LastErrorAt = now
+		if prev != StatusFail {
+			logger.Log.Warnf("health check %s failed: %v", ch.result.Name, err)
+		}
+		return
+	}
+	ch.result.Status = StatusOK
+	if prev == StatusFail {
+		logger.Log.Infof("health check %s recovered", ch.result.Name)
+	}
+}
+
+// Results returns the last result of every check in registration order.
+func (c *Checker) Results() []Result {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := make([]Result, 0, len(c.checks))
+	for _, ch := range c.checks {
+		out = append(out, ch.result)
+	}
+	return out
+}
+
+// Live reports whether no liveness check is failing. Unknown counts as live,
+// so a freshly started process is not killed before its first round.
+func (c *Checker) Live() bool {
+	for _, r := range c.Results() {
+		if r.Kind == Liveness && r.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Ready reports whether every check has passed.
+func (c *Checker) Ready() bool {
+	for _, r := range c.Results() {
+		if r.Status != StatusOK {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Checker) HealthzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.write(w, c.Live())
+	})
+}
+
+func (c *Checker) ReadyzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.write(w, c.Ready())
+	})
+}
+
+func (c *Checker) write(w http.ResponseWriter, ok bool) {
+	status, code := StatusOK, http.StatusOK
+	if !ok {
+		status, code = StatusFail, http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(struct {
+		Status Status   `json:"status"`
+		Checks []Result `json:"checks"`
+	}{status, c.Results()})
+}
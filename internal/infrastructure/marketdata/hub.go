@@ -0,0 +1,114 @@
+package marketdata
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/cache"
+)
+
+// BookEvent tells subscribers that the book under Key has changed.
+type BookEvent struct {
+	Key cache.OrderCacheKey
+	At  time.Time
+}
+
+type liveBook struct {
+	orders    []*domain.Order
+	updatedAt time.Time
+}
+
+// Hub keeps the latest book per (source, pair, side) and notifies
+// subscribers every time one of them changes.
+type Hub struct {
+	mu     sync.RWMutex
+	books  map[cache.OrderCacheKey]*liveBook
+	subs   map[int]chan BookEvent
+	nextID int
+}
+
+var DefaultHub = NewHub()
+
+func NewHub() *Hub {
+	return &Hub{
+		books: make(map[cache.OrderCacheKey]*liveBook),
+		subs:  make(map[int]chan BookEvent),
+	}
+}
+
+// Attach publishes every book stored in c.
+func (h *Hub) Attach(c *cache.OrderCache) {
+	c.OnUpdate(h.Publish)
+}
+
+// Publish stores orders under key and, if they differ from the previous
+// book, sends an event to every subscriber. Slow subscribers don't block
+// the hub: a subscriber with a full channel already has a pending event.
+func (h *Hub) Publish(key cache.OrderCacheKey, orders []*domain.Order, at time.Time) {
+	h.mu.Lock()
+	prev, ok := h.books[key]
+	changed := !ok || !sameOrders(prev.orders, orders)
+	h.books[key] = &liveBook{orders: orders, updatedAt: at}
+	if !changed {
+		h.mu.Unlock()
+		return
+	}
+	subs := make([]chan BookEvent, 0, len(h.subs))
+	for _, ch := range h.subs {
+		subs = append(subs, ch)
+	}
+	h.mu.Unlock()
+
+	ev := BookEvent{Key: key, At: at}
+	for _, ch := range subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel of change events and a func to stop receiving them.
+func (h *Hub) Subscribe() (<-chan BookEvent, func()) {
+	ch := make(chan BookEvent, 1)
+
+	h.mu.Lock()
+	id := h.nextID
+	h.nextID++
+	h.subs[id] = ch
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, id)
+			h.mu.Unlock()
+		})
+	}
+}
+
+// Book returns the latest orders stored under key.
+func (h *Hub) Book(key cache.OrderCacheKey) ([]*domain.Order, time.Time, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	b, ok := h.books[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	return b.orders, b.updatedAt, true
+}
+
+func sameOrders(a, b []*domain.Order) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Price != b[i].Price || a[i].Amount != b[i].Amount {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package marketdata
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/cache"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/parser"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+)
+
+const fetchTimeout = 2 * time.Minute
+
+// Poller keeps the cache warm by fetching every enabled book on its own,
+// independent of any user. A book is never fetched twice at the same time.
+type Poller struct {
+	registry *parser.Registry
+	cache    *cache.OrderCache
+	interval time.Duration
+
+	mu       sync.Mutex
+	inflight map[string]bool
+}
+
+func NewPoller(registry *parser.Registry, c *cache.OrderCache, interval time.Duration) *Poller {
+	return &Poller{
+		registry: registry,
+		cache:    c,
+		interval: interval,
+		inflight: make(map[string]bool),
+	}
+}
+
+// Run polls until ctx is done. Sources enabled or disabled in the registry
+// are picked up on the next round.
+func (p *Poller) Run(ctx context.Context) {
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+
+	for {
+		p.round(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (p *Poller) round(ctx context.Context) {
+	for _, src := range p.registry.Enabled() {
+		for _, pair := range src.Pairs() {
+			id := string(src.Name()) + "|" + string(pair)
+
+			p.mu.Lock()
+			busy := p.inflight[id]
+			p.inflight[id] = true
+			p.mu.Unlock()
+			if busy {
+				continue
+			}
+
+			go func(src parser.OrderBookSource, pair domain.Pair, id string) {
+				defer func() {
+					p.mu.Lock()
+					delete(p.inflight, id)
+					p.mu.Unlock()
+				}()
+				p.fetch(ctx, src, pair)
+			}(src, pair, id)
+		}
+	}
+}
+
+func (p *Poller) fetch(ctx context.Context, src parser.OrderBookSource, pair domain.Pair) {
+	fctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	book, err := src.FetchOrderBook(fctx, pair)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Log.Warnf("poller: failed to fetch %s %s: %v", src.Name(), pair, err)
+		}
+		return
+	}
+
+	p.cache.Set(cache.OrderCacheKey{Source: book.Source, Pair: pair, Side: domain.SideBuy}, book.Asks)
+	p.cache.Set(cache.OrderCacheKey{Source: book.Source, Pair: pair, Side: domain.SideSell}, book.Bids)
+}
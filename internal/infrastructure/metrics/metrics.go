@@ -0,0 +1,185 @@
+// Package metrics holds the Prometheus collectors of the service and the
+// HTTP handler that exposes them.
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "arbitrage"
+
+var (
+	ScrapeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "scraper",
+		Name:      "fetch_duration_seconds",
+		Help:      "Order book fetch latency per source and side.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80},
+	}, []string{"source", "side"})
+
+	ScrapeErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "scraper",
+		Name:      "fetch_errors_total",
+		Help:      "Failed order book fetches per source and side.",
+	}, []string{"source", "side"})
+
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "OrderCache lookups by result (hit, miss, wait).",
+	}, []string{"result"})
+
+	QueueLength = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "queue",
+		Name:      "length",
+		Help:      "Number of jobs in the job queue (jobs:queue list or jobs:stream).",
+	})
+
+	QueueErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "queue",
+		Name:      "blpop_errors_total",
+		Help:      "Job pop errors (BLPOP, BLMOVE or XREADGROUP) other than the wait timeout.",
+	})
+
+	Workers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "dispatcher",
+		Name:      "workers",
+		Help:      "Number of workers known to the dispatcher.",
+	})
+
+	RunningWorkers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "dispatcher",
+		Name:      "running_workers",
+		Help:      "Number of workers currently analysing.",
+	})
+
+	WatchdogRestarts = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "dispatcher",
+		Name:      "watchdog_restarts_total",
+		Help:      "Stale workers soft-restarted by the watchdog.",
+	})
+
+	Opportunities = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "signals",
+		Name:      "opportunities_total",
+		Help:      "Opportunities found per signal type.",
+	}, []string{"type"})
+
+	SignalsDeduplicated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "signals",
+		Name:      "deduplicated_total",
+		Help:      "Signals not sent because the chat already got them.",
+	})
+
+	LifecycleEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "signals",
+		Name:      "lifecycle_events_total",
+		Help:      "Opportunity lifecycle events: opened, improved, worsened, closed.",
+	}, []string{"event"})
+
+	SendFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "signals",
+		Name:      "telegram_send_failures_total",
+		Help:      "Telegram messages that failed to send.",
+	})
+
+	SendDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "signals",
+		Name:      "telegram_send_duration_seconds",
+		Help:      "Telegram send latency.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	OutboxRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "retries_total",
+		Help:      "Outbox messages put back for another attempt.",
+	})
+
+	OutboxDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "dropped_total",
+		Help:      "Outbox messages given up on after a permanent error or too many attempts.",
+	})
+)
+
+// BookAge is the last update time of one cached book side.
+type BookAge struct {
+	Source    string
+	Pair      string
+	Side      string
+	UpdatedAt time.Time
+}
+
+var bookAgeDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "cache", "book_age_seconds"),
+	"Seconds since the cached book was last updated.",
+	[]string{"source", "pair", "side"}, nil,
+)
+
+type bookAgeCollector struct {
+	ages func() []BookAge
+}
+
+func (c bookAgeCollector) Describe(ch chan<- *prometheus.Desc) { ch <- bookAgeDesc }
+
+func (c bookAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+	for _, a := range c.ages() {
+		if a.UpdatedAt.IsZero() {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(bookAgeDesc, prometheus.GaugeValue,
+			now.Sub(a.UpdatedAt).Seconds(), a.Source, a.Pair, a.Side)
+	}
+}
+
+var registerBookAges sync.Once
+
+// RegisterBookAges exposes the age of the books returned by fn at scrape time.
+// Only the first call has an effect.
+func RegisterBookAges(fn func() []BookAge) {
+	registerBookAges.Do(func() {
+		prometheus.MustRegister(bookAgeCollector{ages: fn})
+	})
+}
+
+// ObserveScrape records the latency and the outcome of one fetch.
+func ObserveScrape(source, side string, start time.Time, err error) {
+	ScrapeDuration.WithLabelValues(source, side).Observe(time.Since(start).Seconds())
+	if err != nil {
+		ScrapeErrors.WithLabelValues(source, side).Inc()
+	}
+}
+
+// ObserveSend records the latency and the outcome of one Telegram send.
+func ObserveSend(start time.Time, err error) {
+	SendDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		SendFailures.Inc()
+	}
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -19,6 +19,8 @@ var (
 
 	sem       = make(chan struct{}, 1)
 	defaultTO = 40 * time.Second       
+	// bookDepth — сколько уровней стакана берем с каждой стороны
+	bookDepth = 5
 )
 
 
@@ -58,21 +60,61 @@ func SetChromeParallelLimit(n int) {
 	sem = make(chan struct{}, n)
 }
 
+// SetChromeTimeout limits how long one Chrome tab may live.
+func SetChromeTimeout(d time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultTO = d
+}
+
+// SetBookDepth sets how many levels of every side the sources return.
+func SetBookDepth(n int) {
+	if n < 1 {
+		n = 1
+	}
+	mu.Lock()
+	bookDepth = n
+	mu.Unlock()
+
+	grinexMu.Lock()
+	if grinexClient != nil {
+		c := *grinexClient
+		c.Limit = n
+		grinexClient = &c
+	}
+	grinexMu.Unlock()
+}
+
+func chromeTimeout() time.Duration {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultTO
+}
+
+func depth() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return bookDepth
+}
+
 func NewTab() (context.Context, context.CancelFunc, error) {
 	if allocCtx == nil {
 		return nil, nil, errors.New("chrome allocator is not started")
 	}
 
-	sem <- struct{}{}
+	mu.RLock()
+	s, timeout := sem, defaultTO
+	mu.RUnlock()
+	s <- struct{}{}
 
 	tab, cancel := chromedp.NewContext(allocCtx)
-	tab, cancelTO := context.WithTimeout(tab, defaultTO)
+	tab, cancelTO := context.WithTimeout(tab, timeout)
 
 	cleanup := func() {
 		cancelTO()
 		cancel()
 		select { 
-		case <-sem:
+		case <-s:
 		default:
 		}
 	}
@@ -80,7 +122,7 @@ func NewTab() (context.Context, context.CancelFunc, error) {
 }
 
 func RunSafe(ctx context.Context, actions ...chromedp.Action) error {
-	EnsureAlive()
+	_ = EnsureAlive()
 	backoff := []time.Duration{0, 500 * time.Millisecond, 2 * time.Second}
 	var err error
 	for i, d := range backoff {
@@ -92,29 +134,26 @@ func RunSafe(ctx context.Context, actions ...chromedp.Action) error {
 			return nil
 		}
 		if i == 0 {
-			EnsureAlive()
+			_ = EnsureAlive()
 		}
 	}
 	return err
 }
 
-func EnsureAlive() {
+// EnsureAlive checks that the browser answers and restarts the allocator if
+// it does not. The error is the probe result after the restart attempt.
+func EnsureAlive() error {
 	mu.Lock()
 	defer mu.Unlock()
 
 	if allocCtx == nil {
 		allocCtx, cancelAlloc = chromedp.NewExecAllocator(context.Background(), defaultOptions()...)
 		warmup()
-		return
+		return probe()
 	}
 
-	pctx, pcancel := chromedp.NewContext(allocCtx)
-	defer pcancel()
-	tctx, tcancel := context.WithTimeout(pctx, 5*time.Second)
-	defer tcancel()
-
-	if err := chromedp.Run(tctx, chromedp.Navigate("about:blank")); err == nil {
-		return
+	if err := probe(); err == nil {
+		return nil
 	}
 
 	if cancelAlloc != nil {
@@ -122,6 +161,16 @@ func EnsureAlive() {
 	}
 	allocCtx, cancelAlloc = chromedp.NewExecAllocator(context.Background(), defaultOptions()...)
 	warmup()
+	return probe()
+}
+
+func probe() error {
+	pctx, pcancel := chromedp.NewContext(allocCtx)
+	defer pcancel()
+	tctx, tcancel := context.WithTimeout(pctx, 5*time.Second)
+	defer tcancel()
+
+	return chromedp.Run(tctx, chromedp.Navigate("about:blank"))
 }
 
 
@@ -0,0 +1,159 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+)
+
+const DefaultGrinexAPIURL = "https://grinex.io"
+
+// GrinexClient reads order books from the Grinex JSON depth endpoint
+// (GET {BaseURL}/api/v2/depth?market=usdta7a5&limit=<depth>).
+type GrinexClient struct {
+	BaseURL string
+	HTTP    *http.Client
+	Limit   int
+}
+
+var (
+	grinexMu     sync.RWMutex
+	grinexClient = NewGrinexClient(DefaultGrinexAPIURL)
+)
+
+func NewGrinexClient(baseURL string) *GrinexClient {
+	return &GrinexClient{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 10 * time.Second},
+		Limit:   depth(),
+	}
+}
+
+// SetGrinexAPIURL points Grinex sources at another depth API;
+// an empty URL disables the API and leaves only Chrome scraping.
+func SetGrinexAPIURL(baseURL string) {
+	grinexMu.Lock()
+	defer grinexMu.Unlock()
+	if baseURL == "" {
+		grinexClient = nil
+		return
+	}
+	grinexClient = NewGrinexClient(baseURL)
+}
+
+func currentGrinexClient() *GrinexClient {
+	grinexMu.RLock()
+	defer grinexMu.RUnlock()
+	return grinexClient
+}
+
+// grinexMarket is the market id used by Grinex URLs, e.g. USDT/A7A5 -> usdta7a5.
+func grinexMarket(pair domain.Pair) string {
+	return strings.ToLower(strings.ReplaceAll(string(pair), "/", ""))
+}
+
+type grinexDepth struct {
+	Timestamp int64               `json:"timestamp"`
+	Asks      [][]json.RawMessage `json:"asks"`
+	Bids      [][]json.RawMessage `json:"bids"`
+}
+
+// FetchOrderBook returns the best Limit levels of both sides, asks ascending
+// and bids descending.
+func (c *GrinexClient) FetchOrderBook(ctx context.Context, source domain.Source, pair domain.Pair) (*domain.OrderBook, error) {
+	q := url.Values{}
+	q.Set("market", grinexMarket(pair))
+	if c.Limit > 0 {
+		q.Set("limit", strconv.Itoa(c.Limit))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/v2/depth?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("grinex depth %s: %w", pair, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("grinex depth %s: unexpected status %d", pair, resp.StatusCode)
+	}
+
+	var depth grinexDepth
+	if err := json.NewDecoder(resp.Body).Decode(&depth); err != nil {
+		return nil, fmt.Errorf("grinex depth %s: failed to decode: %w", pair, err)
+	}
+
+	asks, err := grinexLevels(depth.Asks, source, pair, domain.SideBuy)
+	if err != nil {
+		return nil, fmt.Errorf("grinex depth %s asks: %w", pair, err)
+	}
+	bids, err := grinexLevels(depth.Bids, source, pair, domain.SideSell)
+	if err != nil {
+		return nil, fmt.Errorf("grinex depth %s bids: %w", pair, err)
+	}
+	if len(asks) == 0 || len(bids) == 0 {
+		return nil, fmt.Errorf("grinex depth %s: empty orderbook", pair)
+	}
+
+	sort.SliceStable(asks, func(i, j int) bool { return asks[i].Price < asks[j].Price })
+	sort.SliceStable(bids, func(i, j int) bool { return bids[i].Price > bids[j].Price })
+	if c.Limit > 0 {
+		asks = asks[:min(len(asks), c.Limit)]
+		bids = bids[:min(len(bids), c.Limit)]
+	}
+
+	return &domain.OrderBook{Source: source, Pair: pair, Asks: asks, Bids: bids}, nil
+}
+
+// grinexLevels converts [price, volume] entries; numbers may come quoted or not.
+func grinexLevels(raw [][]json.RawMessage, source domain.Source, pair domain.Pair, side domain.OrderSide) ([]*domain.Order, error) {
+	orders := make([]*domain.Order, 0, len(raw))
+	for i, level := range raw {
+		if len(level) < 2 {
+			return nil, fmt.Errorf("level %d: expected [price, volume]", i)
+		}
+		price, err := grinexJSONNumber(level[0])
+		if err != nil {
+			return nil, fmt.Errorf("level %d price: %w", i, err)
+		}
+		amount, err := grinexJSONNumber(level[1])
+		if err != nil {
+			return nil, fmt.Errorf("level %d volume: %w", i, err)
+		}
+		orders = append(orders, &domain.Order{
+			Price:  price,
+			Amount: amount,
+			Sum:    price * amount,
+			Side:   side,
+			Source: source,
+			Pair:   pair,
+		})
+	}
+	return orders, nil
+}
+
+func grinexJSONNumber(raw json.RawMessage) (float64, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return parseGrinexNumber(s)
+	}
+	var f float64
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return 0, err
+	}
+	return f, nil
+}
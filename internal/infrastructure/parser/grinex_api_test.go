@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+)
+
+// grinexServer serves body with the given status on /api/v2/depth and
+// records the last query string.
+func grinexServer(t *testing.T, status int, body string, query *string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/depth" {
+			http.NotFound(w, r)
+			return
+		}
+		if query != nil {
+			*query = r.URL.RawQuery
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGrinexClientQuotedAndUnquoted(t *testing.T) {
+	var query string
+	srv := grinexServer(t, http.StatusOK, `{
+		"timestamp": 1700000000,
+		"asks": [["79.10", "1 000,5"], [79.2, 300]],
+		"bids": [[78.9, "250"], ["78.8", 10.5]]
+	}`, &query)
+
+	c := NewGrinexClient(srv.URL)
+	c.Limit = 10
+	book, err := c.FetchOrderBook(context.Background(), domain.GrinexUSDTRUBSource, domain.Usdtrub)
+	if err != nil {
+		t.Fatalf("FetchOrderBook: %v", err)
+	}
+	if query != "limit=10&market=usdtrub" {
+		t.Errorf("query = %q, want limit=10&market=usdtrub", query)
+	}
+
+	if len(book.Asks) != 2 || len(book.Bids) != 2 {
+		t.Fatalf("got %d asks, %d bids, want 2 and 2", len(book.Asks), len(book.Bids))
+	}
+	ask := book.Asks[0]
+	if ask.Price != 79.10 || ask.Amount != 1000.5 || ask.Sum != ask.Price*ask.Amount {
+		t.Errorf("ask[0] = %+v, want price 79.10, amount 1000.5", ask)
+	}
+	if ask.Side != domain.SideBuy || ask.Source != domain.GrinexUSDTRUBSource || ask.Pair != domain.Usdtrub {
+		t.Errorf("ask[0] side/source/pair = %v/%v/%v", ask.Side, ask.Source, ask.Pair)
+	}
+	if bid := book.Bids[1]; bid.Price != 78.8 || bid.Amount != 10.5 || bid.Side != domain.SideSell {
+		t.Errorf("bid[1] = %+v, want price 78.8, amount 10.5, sell", bid)
+	}
+}
+
+func TestGrinexClientSortsAndLimits(t *testing.T) {
+	srv := grinexServer(t, http.StatusOK, `{
+		"asks": [["80.3", "1"], ["80.1", "1"], ["80.4", "1"], ["80.2", "1"]],
+		"bids": [["79.1", "1"], ["79.4", "1"], ["79.2", "1"], ["79.3", "1"]]
+	}`, nil)
+
+	c := NewGrinexClient(srv.URL)
+	c.Limit = 3
+	book, err := c.FetchOrderBook(context.Background(), domain.GrinexUSDTA7A5Source, domain.Usdta7a5)
+	if err != nil {
+		t.Fatalf("FetchOrderBook: %v", err)
+	}
+
+	wantAsks := []float64{80.1, 80.2, 80.3}
+	wantBids := []float64{79.4, 79.3, 79.2}
+	if got := prices(book.Asks); !slices.Equal(got, wantAsks) {
+		t.Errorf("asks = %v, want %v", got, wantAsks)
+	}
+	if got := prices(book.Bids); !slices.Equal(got, wantBids) {
+		t.Errorf("bids = %v, want %v", got, wantBids)
+	}
+}
+
+func TestGrinexClientErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"non-200", http.StatusBadGateway, `{"error":"bad gateway"}`, "unexpected status 502"},
+		{"malformed json", http.StatusOK, `{"asks": [[`, "failed to decode"},
+		{"short level", http.StatusOK, `{"asks": [["80.1"]], "bids": [["79.1", "1"]]}`, "expected [price, volume]"},
+		{"bad number", http.StatusOK, `{"asks": [["abc", "1"]], "bids": [["79.1", "1"]]}`, "level 0 price"},
+		{"empty side", http.StatusOK, `{"asks": [["80.1", "1"]], "bids": []}`, "empty orderbook"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := grinexServer(t, tt.status, tt.body, nil)
+			_, err := NewGrinexClient(srv.URL).FetchOrderBook(context.Background(), domain.GrinexUSDTRUBSource, domain.Usdtrub)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrinexSourceFallsBackToChrome(t *testing.T) {
+	if logger.Log == nil {
+		logger.InitLog("panic")
+	}
+	srv := grinexServer(t, http.StatusInternalServerError, "", nil)
+	SetGrinexAPIURL(srv.URL)
+	t.Cleanup(func() { SetGrinexAPIURL(DefaultGrinexAPIURL) })
+
+	var calls int
+	chrome := func(price float64) func() ([]*domain.Order, error) {
+		return func() ([]*domain.Order, error) {
+			calls++
+			return []*domain.Order{{Price: price, Amount: 1}}, nil
+		}
+	}
+	s := &grinexSource{
+		source:   domain.GrinexUSDTRUBSource,
+		pair:     domain.Usdtrub,
+		fetchAsk: chrome(80.1),
+		fetchBid: chrome(79.9),
+	}
+
+	book, err := s.FetchOrderBook(context.Background(), domain.Usdtrub)
+	if err != nil {
+		t.Fatalf("FetchOrderBook: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("chrome fetchers called %d times, want 2", calls)
+	}
+	if book.Asks[0].Price != 80.1 || book.Bids[0].Price != 79.9 {
+		t.Errorf("book = %v / %v, want the Chrome levels", book.Asks[0], book.Bids[0])
+	}
+
+	// отменённый контекст — не повод идти в Chrome
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	if _, err := s.FetchOrderBook(ctx, domain.Usdtrub); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if calls != 0 {
+		t.Errorf("chrome fetchers called %d times after cancel, want 0", calls)
+	}
+}
+
+func prices(orders []*domain.Order) []float64 {
+	out := make([]float64, len(orders))
+	for i, o := range orders {
+		out[i] = o.Price
+	}
+	return out
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/metrics"
 	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
 	"github.com/chromedp/chromedp"
 )
@@ -19,10 +20,10 @@ import (
 
 func FetchGrinexAskUSDTRub() ([]*domain.Order, error) {
 	logger.Log.Info("Fetching Grinex USDT/RUB ask")
-    return fetchGrinexOrdersUSDTRUB(
+    return fetchGrinexOrders(
         "https://grinex.io/trading/usdtrub",
         domain.Usdtrub,
-        domain.SideSell,
+        domain.SideBuy,
         "ask_orders_panel",
         "usdtrub",
     )
@@ -30,10 +31,10 @@ func FetchGrinexAskUSDTRub() ([]*domain.Order, error) {
 
 func FetchGrinexBidUSDTRub() ([]*domain.Order, error) {
 	logger.Log.Info("Fetching Grinex USDT/RUB bid")
-    return fetchGrinexOrdersUSDTRUB(
+    return fetchGrinexOrders(
         "https://grinex.io/trading/usdtrub",
         domain.Usdtrub,
-        domain.SideBuy,
+        domain.SideSell,
         "bid_orders_panel",
         "usdtrub",
     )
@@ -41,10 +42,10 @@ func FetchGrinexBidUSDTRub() ([]*domain.Order, error) {
 
 func FetchGrinexAskUSDTA7A5() ([]*domain.Order, error) {
 	logger.Log.Info("Fetching Grinex USDT/A7A5 ask")
-    return fetchGrinexOrdersUSDTA7A5(
+    return fetchGrinexOrders(
         "https://grinex.io/trading/usdta7a5",
         domain.Usdta7a5,
-        domain.SideSell,
+        domain.SideBuy,
         "ask_orders_panel",
         "usdta7a5",
     )
@@ -52,63 +53,52 @@ func FetchGrinexAskUSDTA7A5() ([]*domain.Order, error) {
 
 func FetchGrinexBidUSDTA7A5() ([]*domain.Order, error) {
     logger.Log.Info("Fetching Grinex USDT/A7A5 bid")
-	return fetchGrinexOrdersUSDTA7A5(
+	return fetchGrinexOrders(
         "https://grinex.io/trading/usdta7a5",
         domain.Usdta7a5,
-        domain.SideBuy,
+        domain.SideSell,
         "bid_orders_panel",
         "usdta7a5",
     )
 }
 
-
-func fetchGrinexOrdersUSDTRUB(url string, pair domain.Pair, side domain.OrderSide, panelClass string, marketTab string) ([]*domain.Order, error) {
-    allocatorCtx, cancel := chromedp.NewExecAllocator(context.Background(), chromedp.DefaultExecAllocatorOptions[:]...)
-    defer cancel()
-
-    ctx, cancel := chromedp.NewContext(allocatorCtx)
-    defer cancel()
-
-    ctx, cancel = context.WithTimeout(ctx, 40*time.Second)
-    defer cancel()
-
-    selector := fmt.Sprintf(`div#order_book_holder[data-market="%s_tab"] div.%s table`, marketTab, panelClass)
-
-    var html string
-    err := chromedp.Run(ctx,
-        chromedp.Navigate(url),
-        chromedp.Sleep(3*time.Second),
-        chromedp.WaitVisible(selector+" tbody tr", chromedp.ByQuery),
-        chromedp.OuterHTML(selector, &html, chromedp.ByQuery),
+func FetchGrinexAskA7A5RUB() ([]*domain.Order, error) {
+	logger.Log.Info("Fetching Grinex A7A5/RUB ask")
+    return fetchGrinexOrders(
+        "https://grinex.io/trading/a7a5rub",
+        domain.A7a5rub,
+        domain.SideBuy,
+        "ask_orders_panel",
+        "a7a5rub",
     )
-    if err != nil {
-        return nil, fmt.Errorf("failed to load Grinex orders (RUB): %w", err)
-    }
-
-    return parseGrinexHTML(html, pair, side)
 }
 
-func fetchGrinexOrdersUSDTA7A5(url string, pair domain.Pair, side domain.OrderSide, panelClass string, marketTab string) ([]*domain.Order, error) {
-    allocatorCtx, cancel := chromedp.NewExecAllocator(context.Background(), chromedp.DefaultExecAllocatorOptions[:]...)
-    defer cancel()
-
-    ctx, cancel := chromedp.NewContext(allocatorCtx)
-    defer cancel()
+func FetchGrinexBidA7A5RUB() ([]*domain.Order, error) {
+	logger.Log.Info("Fetching Grinex A7A5/RUB bid")
+    return fetchGrinexOrders(
+        "https://grinex.io/trading/a7a5rub",
+        domain.A7a5rub,
+        domain.SideSell,
+        "bid_orders_panel",
+        "a7a5rub",
+    )
+}
 
-    ctx, cancel = context.WithTimeout(ctx, 40*time.Second)
-    defer cancel()
 
+// fetchGrinexOrders scrapes one side of the order book page in a tab of the shared allocator.
+// It is the fallback for when the depth API is unavailable.
+func fetchGrinexOrders(url string, pair domain.Pair, side domain.OrderSide, panelClass string, marketTab string) ([]*domain.Order, error) {
     selector := fmt.Sprintf(`div#order_book_holder[data-market="%s_tab"] div.%s table`, marketTab, panelClass)
 
     var html string
-    err := chromedp.Run(ctx,
+    err := runOnceWithNewTab(chromeTimeout(),
         chromedp.Navigate(url),
         chromedp.Sleep(3*time.Second),
         chromedp.WaitVisible(selector+" tbody tr", chromedp.ByQuery),
         chromedp.OuterHTML(selector, &html, chromedp.ByQuery),
     )
     if err != nil {
-        return nil, fmt.Errorf("failed to load Grinex orders (A7A5): %w", err)
+        return nil, fmt.Errorf("failed to load Grinex orders (%s): %w", pair, err)
     }
 
     return parseGrinexHTML(html, pair, side)
@@ -122,7 +112,7 @@ func parseGrinexHTML(html string, pair domain.Pair, side domain.OrderSide) ([]*d
 
     var orders []*domain.Order
     rows := doc.Find("tbody tr")
-    for i := 0; i < 5 && i < rows.Length(); i++ {
+    for i := 0; i < depth() && i < rows.Length(); i++ {
         row := rows.Eq(i)
         cols := row.Find("td")
         if cols.Length() < 3 {
@@ -139,6 +129,8 @@ func parseGrinexHTML(html string, pair domain.Pair, side domain.OrderSide) ([]*d
             source = domain.GrinexUSDTA7A5Source
         case domain.Usdtrub:
             source = domain.GrinexUSDTRUBSource
+        case domain.A7a5rub:
+            source = domain.GrinexA7A5RUBSource
         }
 
         orders = append(orders, &domain.Order{
@@ -166,4 +158,55 @@ func parseGrinexNumber(s string) (float64, error) {
 	s = strings.TrimSpace(s)
 	s = strings.ReplaceAll(s, "\n", "")
 	return strconv.ParseFloat(s, 64)
-}
\ No newline at end of file
+}
+
+type grinexSource struct {
+	source   domain.Source
+	pair     domain.Pair
+	fetchAsk func() ([]*domain.Order, error)
+	fetchBid func() ([]*domain.Order, error)
+}
+
+// NewGrinexSource returns the Grinex market for the given pair. Each Grinex
+// market is a separate domain.Source, since fees differ between them.
+// Books are read from the depth API; the page is scraped with Chrome only
+// if the API fails.
+func NewGrinexSource(source domain.Source, pair domain.Pair) OrderBookSource {
+	s := &grinexSource{source: source, pair: pair}
+	switch pair {
+	case domain.Usdta7a5:
+		s.fetchAsk, s.fetchBid = FetchGrinexAskUSDTA7A5, FetchGrinexBidUSDTA7A5
+	case domain.Usdtrub:
+		s.fetchAsk, s.fetchBid = FetchGrinexAskUSDTRub, FetchGrinexBidUSDTRub
+	case domain.A7a5rub:
+		s.fetchAsk, s.fetchBid = FetchGrinexAskA7A5RUB, FetchGrinexBidA7A5RUB
+	}
+	return s
+}
+
+func (s *grinexSource) Name() domain.Source  { return s.source }
+func (s *grinexSource) Pairs() []domain.Pair { return []domain.Pair{s.pair} }
+
+func (s *grinexSource) FetchOrderBook(ctx context.Context, pair domain.Pair) (*domain.OrderBook, error) {
+	if err := supportsPair(s, pair); err != nil {
+		return nil, err
+	}
+
+	if client := currentGrinexClient(); client != nil {
+		start := time.Now()
+		book, err := client.FetchOrderBook(ctx, s.source, pair)
+		metrics.ObserveScrape(string(s.source), "book", start, err)
+		if err == nil {
+			return book, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		logger.Log.Warnf("%s: depth API failed, falling back to Chrome: %v", s.source, err)
+	}
+
+	if s.fetchAsk == nil || s.fetchBid == nil {
+		return nil, fmt.Errorf("%s: no fetcher for pair %s", s.source, pair)
+	}
+	return fetchBothSides(ctx, s.source, pair, s.fetchAsk, s.fetchBid)
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -165,7 +166,7 @@ func FetchRapiraBid() ([]*domain.Order, error) {
 			lastErr = fmt.Errorf("rapira: bid table empty")
 	        continue
 		}
-		limit := 5
+		limit := depth()
 		if n < limit {
 			limit = n
 		}
@@ -270,7 +271,7 @@ func FetchRapiraAsk() ([]*domain.Order, error) {
 			continue
 		}
 
-		limit := 5
+		limit := depth()
 		if len(all) < limit {
 			limit = len(all)
 		}
@@ -285,3 +286,20 @@ func FetchRapiraAsk() ([]*domain.Order, error) {
 
 	return nil, lastErr
 }
+
+
+type rapiraSource struct{}
+
+func NewRapiraSource() OrderBookSource {
+	return rapiraSource{}
+}
+
+func (rapiraSource) Name() domain.Source   { return domain.RapiraSource }
+func (rapiraSource) Pairs() []domain.Pair  { return []domain.Pair{domain.Usdtrub} }
+
+func (s rapiraSource) FetchOrderBook(ctx context.Context, pair domain.Pair) (*domain.OrderBook, error) {
+	if err := supportsPair(s, pair); err != nil {
+		return nil, err
+	}
+	return fetchBothSides(ctx, domain.RapiraSource, pair, FetchRapiraAsk, FetchRapiraBid)
+}
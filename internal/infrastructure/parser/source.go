@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/metrics"
+)
+
+// OrderBookSource is a venue market that can fetch both sides of its order book.
+type OrderBookSource interface {
+	Name() domain.Source
+	Pairs() []domain.Pair
+	FetchOrderBook(ctx context.Context, pair domain.Pair) (*domain.OrderBook, error)
+}
+
+// Registry keeps the known order book sources and which of them are enabled.
+type Registry struct {
+	mu       sync.RWMutex
+	sources  []OrderBookSource
+	disabled map[domain.Source]bool
+}
+
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	DefaultRegistry.Register(NewRapiraSource())
+	DefaultRegistry.Register(NewGrinexSource(domain.GrinexUSDTA7A5Source, domain.Usdta7a5))
+	DefaultRegistry.Register(NewGrinexSource(domain.GrinexUSDTRUBSource, domain.Usdtrub))
+	DefaultRegistry.Register(NewGrinexSource(domain.GrinexA7A5RUBSource, domain.A7a5rub))
+
+	// Пара USDT/RUB на Grinex пока нестабильна, поэтому по умолчанию выключена
+	DefaultRegistry.SetEnabled(domain.GrinexUSDTRUBSource, false)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		disabled: make(map[domain.Source]bool),
+	}
+}
+
+// Register adds a source; a source with the same name is replaced.
+func (r *Registry) Register(src OrderBookSource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, s := range r.sources {
+		if s.Name() == src.Name() {
+			r.sources[i] = src
+			return
+		}
+	}
+	r.sources = append(r.sources, src)
+}
+
+func (r *Registry) SetEnabled(name domain.Source, enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if enabled {
+		delete(r.disabled, name)
+	} else {
+		r.disabled[name] = true
+	}
+}
+
+func (r *Registry) Lookup(name domain.Source) (OrderBookSource, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, s := range r.sources {
+		if s.Name() == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// All returns every registered source in registration order.
+func (r *Registry) All() []OrderBookSource {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]OrderBookSource, len(r.sources))
+	copy(out, r.sources)
+	return out
+}
+
+// Enabled returns the sources that should be polled, in registration order.
+func (r *Registry) Enabled() []OrderBookSource {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]OrderBookSource, 0, len(r.sources))
+	for _, s := range r.sources {
+		if !r.disabled[s.Name()] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func supportsPair(src OrderBookSource, pair domain.Pair) error {
+	for _, p := range src.Pairs() {
+		if p == pair {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: unsupported pair %s", src.Name(), pair)
+}
+
+// fetchBothSides runs the per-side fetchers one after another, giving up
+// between them if the context is already done.
+func fetchBothSides(
+	ctx context.Context,
+	source domain.Source,
+	pair domain.Pair,
+	fetchAsk, fetchBid func() ([]*domain.Order, error),
+) (*domain.OrderBook, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	asks, err := fetchAsk()
+	metrics.ObserveScrape(string(source), "ask", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s asks: %w", source, pair, err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	start = time.Now()
+	bids, err := fetchBid()
+	metrics.ObserveScrape(string(source), "bid", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s bids: %w", source, pair, err)
+	}
+
+	return &domain.OrderBook{
+		Source: source,
+		Pair:   pair,
+		Asks:   asks,
+		Bids:   bids,
+	}, nil
+}
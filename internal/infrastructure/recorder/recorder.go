@@ -0,0 +1,129 @@
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/cache"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+)
+
+// Snapshot is one side of one book as it was stored in the cache.
+// Levels are [price, amount, sum] triples to keep the file small.
+type Snapshot struct {
+	At     time.Time        `json:"at"`
+	Source domain.Source    `json:"source"`
+	Pair   domain.Pair      `json:"pair"`
+	Side   domain.OrderSide `json:"side"`
+	Levels [][3]float64     `json:"levels"`
+}
+
+func (s *Snapshot) Key() cache.OrderCacheKey {
+	return cache.OrderCacheKey{Source: s.Source, Pair: s.Pair, Side: s.Side}
+}
+
+func (s *Snapshot) Orders() []*domain.Order {
+	out := make([]*domain.Order, 0, len(s.Levels))
+	for _, l := range s.Levels {
+		out = append(out, &domain.Order{
+			Price:  l[0],
+			Amount: l[1],
+			Sum:    l[2],
+			Side:   s.Side,
+			Source: s.Source,
+			Pair:   s.Pair,
+		})
+	}
+	return out
+}
+
+// FileRecorder appends snapshots to a JSON lines file.
+type FileRecorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+func NewFileRecorder(path string) (*FileRecorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot file: %w", err)
+	}
+	return &FileRecorder{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Attach records every book stored in c from now on.
+func (r *FileRecorder) Attach(c *cache.OrderCache) {
+	c.OnUpdate(r.Record)
+}
+
+func (r *FileRecorder) Record(key cache.OrderCacheKey, orders []*domain.Order, at time.Time) {
+	snap := Snapshot{
+		At:     at,
+		Source: key.Source,
+		Pair:   key.Pair,
+		Side:   key.Side,
+		Levels: make([][3]float64, 0, len(orders)),
+	}
+	for _, o := range orders {
+		snap.Levels = append(snap.Levels, [3]float64{o.Price, o.Amount, o.Sum})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return
+	}
+	if err := r.enc.Encode(&snap); err != nil {
+		logger.Log.Errorf("failed to record snapshot %s: %v", key, err)
+	}
+}
+
+func (r *FileRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// ReadSnapshots calls fn for every snapshot in the file, in file order.
+func ReadSnapshots(path string, fn func(*Snapshot) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return readSnapshots(f, fn)
+}
+
+func readSnapshots(r io.Reader, fn func(*Snapshot) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	line := 0
+	for sc.Scan() {
+		line++
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var snap Snapshot
+		if err := json.Unmarshal(sc.Bytes(), &snap); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := fn(&snap); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
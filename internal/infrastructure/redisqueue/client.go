@@ -2,53 +2,130 @@ package redisqueue
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Shyyw1e/arbitrage-sync/internal/config"
 	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
 	"github.com/redis/go-redis/v9"
 )
 
 var (
-    redisOpts *redis.Options
+    redisOpts *redis.UniversalOptions
+    redisMode = config.RedisModeSingle
     redisMu   sync.RWMutex
-    RedisClient *redis.Client
+    RedisClient redis.UniversalClient
 )
 
-func InitRedisClient() error {
-    redisOpts = &redis.Options{
-        Addr: "redis-internal:6379",
-        Password: "",
-        DB: 0,
+func redisOptions(c config.Redis) (*redis.UniversalOptions, error) {
+    opts := &redis.UniversalOptions{
+        Password: c.Password,
+        DB:       c.DB,
     }
+    switch c.Mode {
+    case config.RedisModeSingle:
+        opts.Addrs = c.Addrs[:1]
+    case config.RedisModeCluster:
+        opts.Addrs = c.Addrs
+        opts.IsClusterMode = true
+    case config.RedisModeSentinel:
+        opts.MasterName = c.MasterName
+        opts.SentinelPassword = c.SentinelPassword
+        opts.Addrs = c.SentinelAddrs
+        if len(opts.Addrs) == 0 {
+            opts.Addrs = c.Addrs
+        }
+    }
+
+    if c.TLS {
+        tlsCfg := &tls.Config{
+            MinVersion:         tls.VersionTLS12,
+            InsecureSkipVerify: c.TLSInsecure,
+        }
+        if c.TLSCAFile != "" {
+            pem, err := os.ReadFile(c.TLSCAFile)
+            if err != nil {
+                return nil, fmt.Errorf("read redis CA file: %w", err)
+            }
+            pool := x509.NewCertPool()
+            if !pool.AppendCertsFromPEM(pem) {
+                return nil, fmt.Errorf("no certificates in %s", c.TLSCAFile)
+            }
+            tlsCfg.RootCAs = pool
+        }
+        opts.TLSConfig = tlsCfg
+    }
+    return opts, nil
+}
+
+// InitRedisClient validates cfg and connects to Redis.
+func InitRedisClient(cfg config.Redis) error {
+    if err := cfg.Validate(); err != nil {
+        return err
+    }
+    opts, err := redisOptions(cfg)
+    if err != nil {
+        return err
+    }
+    redisOpts = opts
+    redisMode = cfg.Mode
     return resetRedisClient()
 }
 
+// resetRedisClient builds a new client from the saved options; a failover
+// client looks the master up again, so this also recovers from READONLY.
 func resetRedisClient() error {
-    c := redis.NewClient(redisOpts)
+    c := redis.NewUniversalClient(redisOpts)
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
     if err := c.Ping(ctx).Err(); err != nil {
+        _ = c.Close()
         return err
     }
     redisMu.Lock()
     if RedisClient != nil { _ = RedisClient.Close() }
     RedisClient = c
     redisMu.Unlock()
-    logger.Log.Info("Redis client reinitialized")
+    logger.Log.Infof("Redis client reinitialized (%s mode)", redisMode)
     return nil
 }
 
-func getRedis() *redis.Client {
+func getRedis() redis.UniversalClient {
     redisMu.RLock()
     c := RedisClient
     redisMu.RUnlock()
     return c
 }
 
+// Close closes the Redis client; the queue cannot be used afterwards.
+func Close() error {
+    redisMu.Lock()
+    defer redisMu.Unlock()
+    if RedisClient == nil {
+        return nil
+    }
+    err := RedisClient.Close()
+    RedisClient = nil
+    return err
+}
+
+// Ping checks that the current Redis client answers.
+func Ping(ctx context.Context) error {
+    c := getRedis()
+    if c == nil {
+        return errors.New("redis client is not initialized")
+    }
+    return c.Ping(ctx).Err()
+}
+
 func isReadOnlyErr(err error) bool {
     if err == nil { return false }
     s := strings.ToLower(err.Error())
     return strings.Contains(s, "readonly")
-}
\ No newline at end of file
+}
@@ -0,0 +1,155 @@
+package redisqueue
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/config"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+)
+
+// dedupKeyPrefix — hash dedup:<chatID>: ключ сигнала -> последняя отправка
+const dedupKeyPrefix = "dedup:"
+
+var (
+	dedupMu        sync.RWMutex
+	dedupCooldown  = 10 * time.Minute
+	dedupThreshold = 0.05
+)
+
+// SetDedup changes when a signal that persists is sent again.
+func SetDedup(cfg config.Dedup) {
+	dedupMu.Lock()
+	dedupCooldown, dedupThreshold = cfg.Cooldown, cfg.MarginThreshold
+	dedupMu.Unlock()
+}
+
+// sentSignal is the last alert of one signal in a chat.
+type sentSignal struct {
+	Margin float64 `json:"margin"`
+	SentAt int64   `json:"sent_at"` // unix ms
+}
+
+// dedupState holds the alerts of one chat for the duration of a tick.
+type dedupState struct {
+	chatID    int64
+	cooldown  time.Duration
+	threshold float64
+	sent      map[string]sentSignal
+	marked    map[string]sentSignal
+	forgotten map[string]bool
+}
+
+func dedupKey(chatID int64) string {
+	return dedupKeyPrefix + strconv.FormatInt(chatID, 10)
+}
+
+// loadDedup reads the chat's alerts. When Redis fails every signal is let
+// through: a repeated alert is better than a lost one.
+func loadDedup(ctx context.Context, chatID int64) *dedupState {
+	dedupMu.RLock()
+	d := &dedupState{
+		chatID:    chatID,
+		cooldown:  dedupCooldown,
+		threshold: dedupThreshold,
+		sent:      make(map[string]sentSignal),
+		marked:    make(map[string]sentSignal),
+		forgotten: make(map[string]bool),
+	}
+	dedupMu.RUnlock()
+
+	raw, err := getRedis().HGetAll(ctx, dedupKey(chatID)).Result()
+	if err != nil {
+		logger.Log.WithError(err).Warnf("worker %d: failed to load sent signals", chatID)
+		return d
+	}
+	for k, v := range raw {
+		var s sentSignal
+		if err := json.Unmarshal([]byte(v), &s); err != nil {
+			continue
+		}
+		d.sent[k] = s
+	}
+	return d
+}
+
+// allow reports whether the signal should be sent: it is new, its margin
+// moved by at least the threshold since the last alert, or the cooldown passed.
+func (d *dedupState) allow(key string, margin float64, now time.Time) bool {
+	last, ok := d.sent[key]
+	if !ok {
+		return true
+	}
+	if now.Sub(time.UnixMilli(last.SentAt)) >= d.cooldown {
+		return true
+	}
+	// 0.15-0.10 в float64 чуть меньше 0.05: сравниваем с допуском
+	diff := math.Abs(margin - last.Margin)
+	return diff > 0 && diff >= d.threshold-1e-9
+}
+
+func (d *dedupState) mark(key string, margin float64, now time.Time) {
+	s := sentSignal{Margin: margin, SentAt: now.UnixMilli()}
+	d.sent[key] = s
+	d.marked[key] = s
+	delete(d.forgotten, key)
+}
+
+// alerted reports whether the chat got the signal within the cooldown.
+func (d *dedupState) alerted(key string) bool {
+	_, ok := d.sent[key]
+	return ok
+}
+
+// forget drops the signal, so that it is sent at once when it comes back.
+func (d *dedupState) forget(key string) {
+	delete(d.sent, key)
+	delete(d.marked, key)
+	d.forgotten[key] = true
+}
+
+// expired lists the alerts to drop at now: the forgotten ones and the old
+// ones past the cooldown that were not sent again in this tick.
+func (d *dedupState) expired(now time.Time) []string {
+	var out []string
+	for k := range d.forgotten {
+		out = append(out, k)
+	}
+	for k, s := range d.sent {
+		if _, ok := d.marked[k]; !ok && now.Sub(time.UnixMilli(s.SentAt)) >= d.cooldown {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// save writes the new alerts and drops the expired ones; the whole hash
+// expires a cooldown after the last alert.
+func (d *dedupState) save(ctx context.Context) {
+	expired := d.expired(time.Now())
+	if len(d.marked) == 0 && len(expired) == 0 {
+		return
+	}
+
+	key := dedupKey(d.chatID)
+	pipe := getRedis().TxPipeline()
+	if len(expired) > 0 {
+		pipe.HDel(ctx, key, expired...)
+	}
+	if len(d.marked) > 0 {
+		vals := make([]any, 0, 2*len(d.marked))
+		for k, s := range d.marked {
+			data, _ := json.Marshal(s)
+			vals = append(vals, k, data)
+		}
+		pipe.HSet(ctx, key, vals...)
+		pipe.PExpire(ctx, key, d.cooldown)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Log.WithError(err).Warnf("worker %d: failed to save sent signals", d.chatID)
+	}
+}
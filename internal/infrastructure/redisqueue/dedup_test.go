@@ -0,0 +1,93 @@
+package redisqueue
+
+import (
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+func newTestDedup() *dedupState {
+	return &dedupState{
+		chatID:    1,
+		cooldown:  10 * time.Minute,
+		threshold: 0.05,
+		sent:      make(map[string]sentSignal),
+		marked:    make(map[string]sentSignal),
+		forgotten: make(map[string]bool),
+	}
+}
+
+func TestDedupAllow(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		margin float64
+		after  time.Duration
+		want   bool
+	}{
+		{"same margin within cooldown", 0.10, time.Minute, false},
+		{"small move within cooldown", 0.14, time.Minute, false},
+		{"move up by threshold", 0.15, time.Minute, true},
+		{"move down by threshold", 0.04, time.Minute, true},
+		{"same margin at cooldown", 0.10, 10 * time.Minute, true},
+		{"same margin past cooldown", 0.10, time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDedup()
+			d.mark("k", 0.10, t0)
+			if got := d.allow("k", tt.margin, t0.Add(tt.after)); got != tt.want {
+				t.Errorf("allow(%v after %v) = %v, want %v", tt.margin, tt.after, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("new signal", func(t *testing.T) {
+		if !newTestDedup().allow("k", 0, t0) {
+			t.Error("a signal never sent is held back")
+		}
+	})
+	t.Run("zero threshold needs a move", func(t *testing.T) {
+		d := newTestDedup()
+		d.threshold = 0
+		d.mark("k", 0.10, t0)
+		if d.allow("k", 0.10, t0.Add(time.Minute)) {
+			t.Error("unchanged margin is let through with a zero threshold")
+		}
+		if !d.allow("k", 0.11, t0.Add(time.Minute)) {
+			t.Error("changed margin is held back with a zero threshold")
+		}
+	})
+	t.Run("forgotten signal is new again", func(t *testing.T) {
+		d := newTestDedup()
+		d.mark("k", 0.10, t0)
+		d.forget("k")
+		if d.alerted("k") || !d.allow("k", 0.10, t0.Add(time.Second)) {
+			t.Error("forgotten signal is still held back")
+		}
+	})
+}
+
+func TestDedupExpired(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	d := newTestDedup()
+	// загружены из Redis
+	d.sent["fresh"] = sentSignal{Margin: 1, SentAt: now.Add(-time.Minute).UnixMilli()}
+	d.sent["old"] = sentSignal{Margin: 1, SentAt: now.Add(-11 * time.Minute).UnixMilli()}
+	d.sent["old resent"] = sentSignal{Margin: 1, SentAt: now.Add(-11 * time.Minute).UnixMilli()}
+	d.sent["closed"] = sentSignal{Margin: 1, SentAt: now.Add(-time.Minute).UnixMilli()}
+
+	d.mark("old resent", 2, now)
+	d.mark("new", 1, now)
+	d.forget("closed")
+
+	got := d.expired(now)
+	sort.Strings(got)
+	if want := []string{"closed", "old"}; !slices.Equal(got, want) {
+		t.Errorf("expired = %v, want %v", got, want)
+	}
+	if _, ok := d.marked["closed"]; ok {
+		t.Error("forgotten signal would be written back")
+	}
+}
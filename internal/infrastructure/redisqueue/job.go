@@ -0,0 +1,129 @@
+package redisqueue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	JobTypeDetect = "detect-as"
+
+	// JobVersion is the payload version this build writes and understands.
+	JobVersion = 1
+
+	jobDoneKeyPrefix = "jobs:done:"
+	// jobDoneTTL — сколько помним обработанные ключи идемпотентности
+	jobDoneTTL = 24 * time.Hour
+)
+
+// Job is the payload of jobs:queue.
+type Job struct {
+	Type           string    `json:"type"`
+	Version        int       `json:"version"`
+	ChatID         int64     `json:"chat_id"`
+	MinDiff        float64   `json:"min_diff"`
+	MaxSum         float64   `json:"max_sum"`
+	EnqueuedAt     time.Time `json:"enqueued_at"`
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+}
+
+// NewDetectJob creates a detection job for chatID with a fresh idempotency key.
+func NewDetectJob(chatID int64, minDiff, maxSum float64) *Job {
+	return &Job{
+		Type:           JobTypeDetect,
+		Version:        JobVersion,
+		ChatID:         chatID,
+		MinDiff:        minDiff,
+		MaxSum:         maxSum,
+		EnqueuedAt:     time.Now(),
+		IdempotencyKey: newIdempotencyKey(),
+	}
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+func (j *Job) Encode() (string, error) {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// DecodeJob parses a JSON job or, during the migration, the legacy
+// "detect-as:<minDiff>:<maxSum>:<chatID>" string.
+func DecodeJob(raw string) (*Job, error) {
+	if !strings.HasPrefix(strings.TrimSpace(raw), "{") {
+		return decodeLegacyJob(raw)
+	}
+
+	var j Job
+	if err := json.Unmarshal([]byte(raw), &j); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+	if j.Version < 1 || j.Version > JobVersion {
+		return nil, fmt.Errorf("unsupported job version %d", j.Version)
+	}
+	if j.Type != JobTypeDetect {
+		return nil, fmt.Errorf("unknown job type %q", j.Type)
+	}
+	if j.ChatID == 0 {
+		return nil, fmt.Errorf("job without chat id")
+	}
+	return &j, nil
+}
+
+func decodeLegacyJob(raw string) (*Job, error) {
+	if !strings.HasPrefix(raw, JobTypeDetect+":") {
+		return nil, fmt.Errorf("unknown job: %s", raw)
+	}
+
+	parts := strings.Split(raw, ":")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("invalid job format: %s", raw)
+	}
+
+	min, err1 := strconv.ParseFloat(parts[1], 64)
+	max, err2 := strconv.ParseFloat(parts[2], 64)
+	chatID, err3 := strconv.ParseInt(parts[3], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, fmt.Errorf("job parse error: %v %v %v (%s)", err1, err2, err3, raw)
+	}
+
+	return &Job{
+		Type:    JobTypeDetect,
+		Version: 0,
+		ChatID:  chatID,
+		MinDiff: min,
+		MaxSum:  max,
+	}, nil
+}
+
+// jobDone reports whether a job with this idempotency key was already handled.
+func jobDone(ctx context.Context, j *Job) bool {
+	if j.IdempotencyKey == "" {
+		return false
+	}
+	n, err := getRedis().Exists(ctx, jobDoneKeyPrefix+j.IdempotencyKey).Result()
+	return err == nil && n > 0
+}
+
+// markJobDone remembers the idempotency key so a redelivered copy is skipped.
+func markJobDone(ctx context.Context, j *Job) error {
+	if j.IdempotencyKey == "" {
+		return nil
+	}
+	return getRedis().SetNX(ctx, jobDoneKeyPrefix+j.IdempotencyKey, time.Now().Unix(), jobDoneTTL).Err()
+}
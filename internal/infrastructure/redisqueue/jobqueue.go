@@ -0,0 +1,284 @@
+package redisqueue
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	QueueBackendList    = "list"
+	QueueBackendStreams = "streams"
+
+	QueueModeBLPop    = "blpop"
+	QueueModeReliable = "reliable"
+
+	processingKeyPrefix = "jobs:processing:"
+	consumerKeyPrefix   = "jobs:consumer:"
+	consumersKey        = "jobs:consumers"
+
+	// consumerTTL — сколько живет ключ консьюмера без продления
+	consumerTTL = 30 * time.Second
+	popTimeout  = 10 * time.Second
+)
+
+// delivery is one job taken from the queue, to be acked once handled.
+type delivery struct {
+	payload string
+	id      string // stream entry id, empty for lists
+}
+
+// queuedJob is a job sitting in the queue or taken but not acked yet.
+type queuedJob struct {
+	payload string
+	key     string // list holding it
+	id      string // stream entry id
+}
+
+// jobQueue is a backend of the job queue.
+type jobQueue interface {
+	push(ctx context.Context, payload string) error
+	// pop blocks up to popTimeout and returns redis.Nil when nothing came.
+	pop(ctx context.Context) (*delivery, error)
+	ack(ctx context.Context, d *delivery) error
+	// maintain is called every watchdog round.
+	maintain(ctx context.Context)
+	// release gives the unacked jobs back to the queue on shutdown.
+	release(ctx context.Context) error
+	// list returns the waiting and the taken but unacked jobs.
+	list(ctx context.Context) ([]queuedJob, error)
+	remove(ctx context.Context, j queuedJob) error
+	length(ctx context.Context) (int64, error)
+}
+
+var (
+	queueMu      sync.Mutex
+	queueBackend = QueueBackendList
+	queueMode    = QueueModeBLPop
+	jobs         jobQueue
+)
+
+// SetQueueBackend selects where jobs are stored: "list" (default, jobs:queue)
+// or "streams" (jobs:stream with a consumer group). It must be called before
+// the queue is first used.
+func SetQueueBackend(backend string) error {
+	switch backend {
+	case QueueBackendList, QueueBackendStreams:
+	default:
+		return fmt.Errorf("unknown job queue backend %q", backend)
+	}
+	queueMu.Lock()
+	queueBackend = backend
+	queueMu.Unlock()
+	return nil
+}
+
+// SetQueueMode selects how the list backend takes jobs: "blpop" (default) or
+// "reliable" (BLMOVE into a per-consumer processing list with acks).
+// Config.Validate rejects reliable mode together with redis cluster.
+func SetQueueMode(mode string) error {
+	switch mode {
+	case QueueModeBLPop, QueueModeReliable:
+	default:
+		return fmt.Errorf("unknown job queue mode %q", mode)
+	}
+	queueMu.Lock()
+	queueMode = mode
+	queueMu.Unlock()
+	return nil
+}
+
+// getJobQueue returns the configured backend, creating it on first use.
+func getJobQueue() jobQueue {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+
+	if jobs != nil {
+		return jobs
+	}
+	switch {
+	case queueBackend == QueueBackendStreams:
+		jobs = newStreamQueue(instanceID)
+	case queueMode == QueueModeReliable:
+		jobs = newReliableQueue(instanceID)
+	default:
+		jobs = blpopQueue{}
+	}
+	return jobs
+}
+
+func consumerID() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), strconv.FormatInt(time.Now().UnixNano(), 36))
+}
+
+// listQueue holds what both list-based backends share.
+type listQueue struct{}
+
+func (listQueue) push(ctx context.Context, payload string) error {
+	return getRedis().RPush(ctx, JobQueueKey, payload).Err()
+}
+
+func (listQueue) length(ctx context.Context) (int64, error) {
+	return getRedis().LLen(ctx, JobQueueKey).Result()
+}
+
+func (listQueue) remove(ctx context.Context, j queuedJob) error {
+	return getRedis().LRem(ctx, j.key, 0, j.payload).Err()
+}
+
+func listJobs(ctx context.Context, keys ...string) ([]queuedJob, error) {
+	var out []queuedJob
+	for _, key := range keys {
+		vals, err := getRedis().LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vals {
+			out = append(out, queuedJob{payload: v, key: key})
+		}
+	}
+	return out, nil
+}
+
+// blpopQueue is the original at-most-once queue: a job is gone once popped.
+type blpopQueue struct{ listQueue }
+
+func (blpopQueue) pop(ctx context.Context) (*delivery, error) {
+	res, err := getRedis().BLPop(ctx, popTimeout, JobQueueKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(res) < 2 {
+		return nil, redis.Nil
+	}
+	return &delivery{payload: res[1]}, nil
+}
+
+func (blpopQueue) ack(context.Context, *delivery) error { return nil }
+func (blpopQueue) maintain(context.Context)             {}
+func (blpopQueue) release(context.Context) error        { return nil }
+
+func (blpopQueue) list(ctx context.Context) ([]queuedJob, error) {
+	return listJobs(ctx, JobQueueKey)
+}
+
+// reliableQueue moves every job into jobs:processing:<consumer> and removes
+// it from there on ack. A consumer keeps jobs:consumer:<id> alive; the lists
+// of consumers whose key expired are pushed back to the front of the queue.
+type reliableQueue struct {
+	listQueue
+	id string
+}
+
+func newReliableQueue(id string) *reliableQueue {
+	q := &reliableQueue{id: id}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	q.heartbeat(ctx)
+	logger.Log.Infof("Reliable job queue: consumer %s", id)
+	return q
+}
+
+func (q *reliableQueue) processingKey() string { return processingKeyPrefix + q.id }
+
+func (q *reliableQueue) heartbeat(ctx context.Context) {
+	c := getRedis()
+	if err := c.SAdd(ctx, consumersKey, q.id).Err(); err != nil {
+		logger.Log.WithError(err).Warn("failed to register queue consumer")
+	}
+	if err := c.Set(ctx, consumerKeyPrefix+q.id, time.Now().Unix(), consumerTTL).Err(); err != nil {
+		logger.Log.WithError(err).Warn("failed to refresh queue consumer")
+	}
+}
+
+func (q *reliableQueue) pop(ctx context.Context) (*delivery, error) {
+	job, err := getRedis().BLMove(ctx, JobQueueKey, q.processingKey(), "LEFT", "RIGHT", popTimeout).Result()
+	if err != nil {
+		return nil, err
+	}
+	return &delivery{payload: job}, nil
+}
+
+func (q *reliableQueue) ack(ctx context.Context, d *delivery) error {
+	return getRedis().LRem(ctx, q.processingKey(), 1, d.payload).Err()
+}
+
+// maintain refreshes our consumer key and recovers the jobs of dead consumers.
+func (q *reliableQueue) maintain(ctx context.Context) {
+	q.heartbeat(ctx)
+
+	c := getRedis()
+	ids, err := c.SMembers(ctx, consumersKey).Result()
+	if err != nil {
+		logger.Log.WithError(err).Warn("failed to list queue consumers")
+		return
+	}
+	for _, id := range ids {
+		if id == q.id {
+			continue
+		}
+		alive, err := c.Exists(ctx, consumerKeyPrefix+id).Result()
+		if err != nil || alive > 0 {
+			continue
+		}
+		n, err := requeueProcessing(ctx, processingKeyPrefix+id)
+		if err != nil {
+			logger.Log.WithError(err).Warnf("failed to recover jobs of consumer %s", id)
+			continue
+		}
+		if n > 0 {
+			logger.Log.Warnf("Recovered %d jobs of dead consumer %s", n, id)
+		}
+		_ = c.SRem(ctx, consumersKey, id).Err()
+	}
+}
+
+func (q *reliableQueue) release(ctx context.Context) error {
+	c := getRedis()
+	n, err := requeueProcessing(ctx, q.processingKey())
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		logger.Log.Infof("Returned %d unacked jobs to the queue", n)
+	}
+	_ = c.SRem(ctx, consumersKey, q.id).Err()
+	return c.Del(ctx, consumerKeyPrefix+q.id).Err()
+}
+
+func (q *reliableQueue) list(ctx context.Context) ([]queuedJob, error) {
+	ids, err := getRedis().SMembers(ctx, consumersKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{JobQueueKey}
+	for _, id := range ids {
+		keys = append(keys, processingKeyPrefix+id)
+	}
+	return listJobs(ctx, keys...)
+}
+
+// requeueProcessing moves every job of a processing list back to the front
+// of jobs:queue, oldest first in line.
+func requeueProcessing(ctx context.Context, key string) (int, error) {
+	c := getRedis()
+	n := 0
+	for {
+		_, err := c.LMove(ctx, key, JobQueueKey, "RIGHT", "LEFT").Result()
+		if err == redis.Nil {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+}
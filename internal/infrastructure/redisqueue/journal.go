@@ -0,0 +1,76 @@
+package redisqueue
+
+import (
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/usecase"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/db"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/metrics"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+)
+
+var journal db.OpportunityRepository
+
+// InitJournal sets where workers record the opportunities they find.
+func InitJournal(j db.OpportunityRepository) {
+	journal = j
+}
+
+func recordOpportunities(chatID int64, typ domain.SignalType, ops []*domain.Opportunity) {
+	metrics.Opportunities.WithLabelValues(string(typ)).Add(float64(len(ops)))
+	if journal == nil {
+		return
+	}
+	for _, op := range ops {
+		amount := op.BuyAmount
+		if op.Volume > 0 {
+			amount = op.Volume
+		}
+		rec := &db.OpportunityRecord{
+			ChatID:       chatID,
+			Type:         typ,
+			BuyExchange:  op.BuyExchange,
+			SellExchange: op.SellExchange,
+			BuyPair:      op.BuyPair,
+			SellPair:     op.SellPair,
+			BuyPrice:     op.BuyPrice,
+			SellPrice:    op.SellPrice,
+			Amount:       amount,
+			Margin:       op.ProfitMargin,
+			TotalProfit:  op.TotalProfit,
+			DetectedAt:   op.CreatedAt,
+		}
+		if err := journal.Save(rec); err != nil {
+			logger.Log.WithError(err).Warnf("worker %d: failed to record %s opportunity", chatID, typ)
+		}
+	}
+}
+
+func recordTriangles(chatID int64, ops []*domain.TriangleOpportunity) {
+	metrics.Opportunities.WithLabelValues(string(domain.SignalTriangle)).Add(float64(len(ops)))
+	if journal == nil {
+		return
+	}
+	for _, op := range ops {
+		if len(op.Legs) == 0 {
+			continue
+		}
+		first, last := op.Legs[0], op.Legs[len(op.Legs)-1]
+		rec := &db.OpportunityRecord{
+			ChatID:       chatID,
+			Type:         domain.SignalTriangle,
+			BuyExchange:  first.Source,
+			SellExchange: last.Source,
+			BuyPair:      first.Pair,
+			SellPair:     last.Pair,
+			BuyPrice:     first.Price,
+			SellPrice:    last.Price,
+			Amount:       op.Volume,
+			Margin:       usecase.TriangleMargin(op),
+			TotalProfit:  op.TotalProfit,
+			DetectedAt:   op.CreatedAt,
+		}
+		if err := journal.Save(rec); err != nil {
+			logger.Log.WithError(err).Warnf("worker %d: failed to record triangle opportunity", chatID)
+		}
+	}
+}
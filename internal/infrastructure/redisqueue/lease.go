@@ -0,0 +1,192 @@
+package redisqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/config"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	leaseKeyPrefix = "lease:chat:"
+	// leaseChatsKey — hash chatID -> параметры анализа, по нему другой инстанс подхватывает чат
+	leaseChatsKey = "leases:chats"
+	// leaseTTL — продлевается каждым раундом watchdog, пока HB воркера свежий
+	leaseTTL = config.LeaseTTL
+)
+
+var errLeaseHeld = errors.New("chat is owned by another instance")
+
+// instanceID identifies this process as a lease owner and a queue consumer.
+var instanceID = consumerID()
+
+var (
+	renewLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+)
+
+// leaseParams are the analysis parameters of a chat, kept for the instance
+// that takes the chat over.
+type leaseParams struct {
+	MinDiff float64 `json:"min_diff"`
+	MaxSum  float64 `json:"max_sum"`
+}
+
+func leaseKey(chatID int64) string {
+	return leaseKeyPrefix + strconv.FormatInt(chatID, 10)
+}
+
+// acquireLease makes this instance the owner of chatID, or keeps it the
+// owner. It fails with errLeaseHeld when another instance owns the chat.
+func acquireLease(ctx context.Context, chatID int64) error {
+	ok, err := getRedis().SetNX(ctx, leaseKey(chatID), instanceID, leaseTTL).Result()
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+	renewed, err := renewLease(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if !renewed {
+		return errLeaseHeld
+	}
+	return nil
+}
+
+// renewLease extends the lease if this instance still holds it.
+func renewLease(ctx context.Context, chatID int64) (bool, error) {
+	n, err := renewLeaseScript.Run(ctx, getRedis(), []string{leaseKey(chatID)}, instanceID, leaseTTL.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// releaseLease gives up the lease if this instance holds it, so another
+// instance can take the chat over right away.
+func releaseLease(ctx context.Context, chatID int64) error {
+	return releaseLeaseScript.Run(ctx, getRedis(), []string{leaseKey(chatID)}, instanceID).Err()
+}
+
+func saveLeaseParams(ctx context.Context, chatID int64, minDiff, maxSum float64) error {
+	data, err := json.Marshal(leaseParams{MinDiff: minDiff, MaxSum: maxSum})
+	if err != nil {
+		return err
+	}
+	return getRedis().HSet(ctx, leaseChatsKey, strconv.FormatInt(chatID, 10), data).Err()
+}
+
+// dropLease ends the analysis of chatID for every instance: the owner sees
+// its lease gone and the chat no longer wanted, and stops its worker.
+func dropLease(ctx context.Context, chatID int64) error {
+	c := getRedis()
+	if err := c.HDel(ctx, leaseChatsKey, strconv.FormatInt(chatID, 10)).Err(); err != nil {
+		return err
+	}
+	return c.Del(ctx, leaseKey(chatID)).Err()
+}
+
+func loadLeaseParams(ctx context.Context) (map[int64]leaseParams, error) {
+	raw, err := getRedis().HGetAll(ctx, leaseChatsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int64]leaseParams, len(raw))
+	for k, v := range raw {
+		chatID, err := strconv.ParseInt(k, 10, 64)
+		if err != nil {
+			continue
+		}
+		var p leaseParams
+		if err := json.Unmarshal([]byte(v), &p); err != nil {
+			logger.Log.WithError(err).Warnf("invalid lease params for chat %s", k)
+			continue
+		}
+		out[chatID] = p
+	}
+	return out, nil
+}
+
+// maintainLeases runs every watchdog round. It renews the leases of local
+// workers with a fresh heartbeat, pushes changed parameters to them, stops
+// the ones whose chat is owned elsewhere or no longer wanted, and takes over
+// wanted chats whose lease has expired.
+func maintainLeases(ctx context.Context, bot *tgbotapi.BotAPI) {
+	params, err := loadLeaseParams(ctx)
+	if err != nil {
+		logger.Log.WithError(err).Warn("failed to load chat leases")
+		return
+	}
+
+	now := time.Now()
+	for _, w := range dispatcher.list() {
+		if !w.isRunning() {
+			continue
+		}
+		if hb := w.lastHB(); !hb.IsZero() && now.Sub(hb) > staleAfter {
+			continue // пусть watchdog сначала перезапустит воркер
+		}
+
+		p, wanted := params[w.chatID]
+		if wanted {
+			renewed, err := renewLease(ctx, w.chatID)
+			if err != nil {
+				logger.Log.WithError(err).Warnf("failed to renew lease chat=%d", w.chatID)
+				continue
+			}
+			if !renewed {
+				err = acquireLease(ctx, w.chatID)
+				renewed = err == nil
+			}
+			if renewed {
+				if p.MinDiff != w.getMin() || p.MaxSum != w.getMax() {
+					logger.Log.Infof("Lease: new params for chat=%d", w.chatID)
+					dispatcher.update(w.chatID, p.MinDiff, p.MaxSum)
+				}
+				continue
+			}
+		}
+
+		logger.Log.Warnf("Lease: chat=%d is not ours anymore (wanted=%v) -> stop worker", w.chatID, wanted)
+		if err := dispatcher.stop(w.chatID, userStore); err != nil {
+			logger.Log.WithError(err).Warnf("failed to stop worker chat=%d", w.chatID)
+		}
+	}
+
+	for chatID, p := range params {
+		if dispatcher.isRunning(chatID) {
+			continue
+		}
+		n, err := getRedis().Exists(ctx, leaseKey(chatID)).Result()
+		if err != nil || n > 0 {
+			continue
+		}
+		st, err := userStore.Get(chatID)
+		if err != nil || st == nil || st.Step != "ready_to_run" {
+			continue
+		}
+
+		logger.Log.Infof("Lease: taking over chat=%d", chatID)
+		if err := dispatcher.start(chatID, p.MinDiff, p.MaxSum, bot, userStore); err != nil && !errors.Is(err, errLeaseHeld) {
+			logger.Log.WithError(err).Warnf("Lease: takeover failed chat=%d", chatID)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package redisqueue
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/usecase"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/db"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+)
+
+var lifecycles db.LifecycleRepository
+
+// InitLifecycles sets where workers keep the lifecycles of the opportunities.
+func InitLifecycles(repo db.LifecycleRepository) {
+	lifecycles = repo
+}
+
+// signal is one opportunity found in a tick with its message.
+type signal struct {
+	obs    usecase.Observation
+	venues []domain.Source
+	text   string
+}
+
+func pairSignal(typ domain.SignalType, op *domain.Opportunity, text string) signal {
+	return signal{
+		obs:    usecase.ObserveOpportunity(typ, op),
+		venues: []domain.Source{op.BuyExchange, op.SellExchange},
+		text:   text,
+	}
+}
+
+func triangleSignal(op *domain.TriangleOpportunity, text string) signal {
+	venues := make([]domain.Source, 0, len(op.Legs))
+	for _, l := range op.Legs {
+		venues = append(venues, l.Source)
+	}
+	return signal{obs: usecase.ObserveTriangle(op), venues: venues, text: text}
+}
+
+var signalTitles = map[domain.SignalType]string{
+	domain.SignalFact:      "Фактический арбитраж",
+	domain.SignalPotential: "Потенциальный арбитраж",
+	domain.SignalReverse:   "Обратный потенциальный арбитраж",
+	domain.SignalDepth:     "Арбитраж по стакану",
+	domain.SignalTriangle:  "Треугольный арбитраж",
+}
+
+// lifecycleTracker returns the worker's tracker, continuing the lifecycles
+// the chat left open. It returns nil when they cannot be read: signals are
+// then sent without lifecycle events rather than opened twice.
+func (w *worker) lifecycleTracker(maxGap time.Duration) *usecase.LifecycleTracker {
+	if w.tracker == nil {
+		var open []*domain.Lifecycle
+		if lifecycles != nil {
+			var err error
+			if open, err = lifecycles.OpenLifecycles(w.chatID); err != nil {
+				logger.Log.WithError(err).Warnf("worker %d: failed to load lifecycles", w.chatID)
+				return nil
+			}
+		}
+		w.tracker = usecase.NewLifecycleTracker(w.chatID, open, maxGap)
+	}
+	w.tracker.SetMaxGap(maxGap)
+	return w.tracker
+}
+
+// lifecycleMaxGap is how long an opportunity may go unseen before it counts
+// as closed when it was last seen: two of the longest intervals between ticks.
+func (w *worker) lifecycleMaxGap() time.Duration {
+	t := currentTiming()
+	longest := t.fallback
+	if g := w.gap(t); g > longest {
+		longest = g
+	}
+	if 2*longest < staleAfter {
+		return staleAfter
+	}
+	return 2 * longest
+}
+
+func saveLifecycles(chatID int64, ls []*domain.Lifecycle) {
+	if lifecycles == nil {
+		return
+	}
+	for _, l := range ls {
+		if err := lifecycles.SaveLifecycle(l); err != nil {
+			logger.Log.WithError(err).Warnf("worker %d: failed to save lifecycle %s", chatID, l.Key)
+		}
+	}
+}
+
+// lifecycleNote is appended to the message of an opportunity that is
+// already open.
+func lifecycleNote(ev domain.LifecycleEvent, l *domain.Lifecycle) string {
+	if l == nil || ev == domain.LifecycleOpened {
+		return ""
+	}
+	var head string
+	switch ev {
+	case domain.LifecycleImproved:
+		head = "📈 Маржа выросла"
+	case domain.LifecycleWorsened:
+		head = "📉 Маржа снизилась"
+	default:
+		head = "⏱ Всё ещё открыт"
+	}
+	return fmt.Sprintf("\n%s: держится %v, пик маржи %.2f", head, l.Duration().Round(time.Second), l.PeakMargin)
+}
+
+func closedText(l *domain.Lifecycle) string {
+	return fmt.Sprintf("✅ %s закрыт\nBuy %s %s → Sell %s %s\nДержался: %v\nМаржа: %.2f на открытии, пик %.2f, последняя %.2f",
+		signalTitles[l.Type], l.BuyExchange, l.BuyPair, l.SellExchange, l.SellPair,
+		l.Duration().Round(time.Second), l.OpenMargin, l.PeakMargin, l.LastMargin)
+}
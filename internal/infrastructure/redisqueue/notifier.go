@@ -0,0 +1,17 @@
+package redisqueue
+
+// Notifier shows the signals of a chat. Each signal, identified by its key,
+// is one live message: Signal shows or updates it, Closed marks it closed,
+// Forget drops it without a message, so the next Signal starts a new one.
+type Notifier interface {
+	Signal(chatID int64, key, text string) error
+	Closed(chatID int64, key, text string) error
+	Forget(chatID int64, key string) error
+}
+
+var notifier Notifier
+
+// InitNotifier sets how workers deliver signals; call it before StartWorkerLoop.
+func InitNotifier(n Notifier) {
+	notifier = n
+}
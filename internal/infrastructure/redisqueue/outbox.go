@@ -0,0 +1,350 @@
+package redisqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/config"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/metrics"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// outboxChatKeyPrefix — list outbox:chat:<chatID>, сообщения чата по порядку
+	outboxChatKeyPrefix = "outbox:chat:"
+	// outboxChatsKey — set чатов, у которых есть неотправленные сообщения
+	outboxChatsKey = "outbox:chats"
+	// outboxLockKeyPrefix — кто досылает сообщения чата, у которого нет владельца
+	outboxLockKeyPrefix = "outbox:lock:"
+	outboxLockTTL       = 30 * time.Second
+
+	outboxPoll = 250 * time.Millisecond
+)
+
+const (
+	outboxSignal = "signal"
+	outboxClosed = "closed"
+	outboxForget = "forget" // ничего не шлет, идет в очереди по порядку с остальными
+)
+
+// outboxMessage is one Notifier call waiting for delivery.
+type outboxMessage struct {
+	Kind       string `json:"kind"`
+	ChatID     int64  `json:"chat_id"`
+	Key        string `json:"key"`
+	Text       string `json:"text"`
+	Attempts   int    `json:"attempts,omitempty"`
+	EnqueuedAt int64  `json:"enqueued_at"` // unix ms
+}
+
+// Outbox is the Notifier of the workers: it stores their messages in Redis
+// and delivers them through sender within a global and a per-chat rate,
+// retrying failed sends. Messages of one chat keep their order.
+type Outbox struct {
+	sender Notifier
+	cfg    config.Outbox
+	wake   chan struct{}
+
+	// дальше — только из горутины доставки
+	global *tokenBucket
+	chats  map[int64]*outboxChat
+	// pausedUntil — после 429 Telegram тормозит весь бот, а не один чат
+	pausedUntil time.Time
+}
+
+type outboxChat struct {
+	bucket  *tokenBucket
+	retryAt time.Time
+}
+
+func NewOutbox(sender Notifier, cfg config.Outbox) *Outbox {
+	return &Outbox{
+		sender: sender,
+		cfg:    cfg,
+		wake:   make(chan struct{}, 1),
+		global: newTokenBucket(cfg.GlobalRate, cfg.GlobalBurst),
+		chats:  make(map[int64]*outboxChat),
+	}
+}
+
+func outboxChatKey(chatID int64) string {
+	return outboxChatKeyPrefix + strconv.FormatInt(chatID, 10)
+}
+
+func outboxLockKey(chatID int64) string {
+	return outboxLockKeyPrefix + strconv.FormatInt(chatID, 10)
+}
+
+func (o *Outbox) Signal(chatID int64, key, text string) error {
+	return o.enqueue(outboxMessage{Kind: outboxSignal, ChatID: chatID, Key: key, Text: text})
+}
+
+func (o *Outbox) Closed(chatID int64, key, text string) error {
+	return o.enqueue(outboxMessage{Kind: outboxClosed, ChatID: chatID, Key: key, Text: text})
+}
+
+func (o *Outbox) Forget(chatID int64, key string) error {
+	return o.enqueue(outboxMessage{Kind: outboxForget, ChatID: chatID, Key: key})
+}
+
+func (o *Outbox) enqueue(m outboxMessage) error {
+	m.EnqueuedAt = time.Now().UnixMilli()
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	// сначала сообщение, потом чат в set: так forgetChat не потеряет его
+	// (в кластере это разные слоты, MULTI не подходит)
+	if err := getRedis().RPush(ctx, outboxChatKey(m.ChatID), data).Err(); err != nil {
+		return err
+	}
+	if err := getRedis().SAdd(ctx, outboxChatsKey, m.ChatID).Err(); err != nil {
+		return err
+	}
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start delivers messages until ctx is done; Shutdown waits for it.
+func (o *Outbox) Start(ctx context.Context) {
+	loops.Add(1)
+	go func() {
+		defer loops.Done()
+		t := time.NewTicker(outboxPoll)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Log.Info("Outbox stopped")
+				return
+			case <-t.C:
+			case <-o.wake:
+			}
+			o.deliver(ctx)
+		}
+	}()
+}
+
+// deliver goes over the chats with pending messages once.
+func (o *Outbox) deliver(ctx context.Context) {
+	ids, err := getRedis().SMembers(ctx, outboxChatsKey).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Log.WithError(err).Warn("outbox: failed to list chats")
+		}
+		return
+	}
+	now := time.Now()
+	if now.Before(o.pausedUntil) {
+		return
+	}
+	for _, s := range ids {
+		chatID, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			continue
+		}
+		c := o.chat(chatID)
+		if now.Before(c.retryAt) || !o.mayDeliver(ctx, chatID) {
+			continue
+		}
+		if !o.drain(ctx, chatID, c) {
+			return // глобальный лимит исчерпан до следующего раунда
+		}
+	}
+}
+
+func (o *Outbox) chat(chatID int64) *outboxChat {
+	c, ok := o.chats[chatID]
+	if !ok {
+		c = &outboxChat{bucket: newTokenBucket(o.cfg.ChatRate, o.cfg.ChatBurst)}
+		o.chats[chatID] = c
+	}
+	return c
+}
+
+// mayDeliver reports whether this instance delivers the chat's messages:
+// the chat's lease owner does, so its live messages are edited by the
+// instance that knows their IDs. Messages of a chat without an owner
+// (stopped, or its instance died) go to whoever takes the outbox lock.
+func (o *Outbox) mayDeliver(ctx context.Context, chatID int64) bool {
+	if dispatcher.isRunning(chatID) {
+		return true
+	}
+	owner, err := getRedis().Get(ctx, leaseKey(chatID)).Result()
+	if err == nil {
+		return owner == instanceID
+	}
+	if !errors.Is(err, redis.Nil) {
+		return false
+	}
+	ok, err := getRedis().SetNX(ctx, outboxLockKey(chatID), instanceID, outboxLockTTL).Result()
+	if err != nil {
+		return false
+	}
+	if ok {
+		return true
+	}
+	n, err := renewLeaseScript.Run(ctx, getRedis(), []string{outboxLockKey(chatID)}, instanceID, outboxLockTTL.Milliseconds()).Int()
+	return err == nil && n == 1
+}
+
+// drain sends the chat's messages in order while the limits allow. A failed
+// message stays first and holds the chat back until its retry time; a 429
+// holds back every chat. drain reports false when the global limit is used
+// up or Telegram throttles the bot.
+func (o *Outbox) drain(ctx context.Context, chatID int64, c *outboxChat) bool {
+	key := outboxChatKey(chatID)
+	for {
+		raw, err := getRedis().LIndex(ctx, key, 0).Result()
+		if errors.Is(err, redis.Nil) {
+			o.forgetChat(ctx, chatID)
+			return true
+		}
+		if err != nil {
+			logger.Log.WithError(err).Warnf("outbox: failed to read chat %d", chatID)
+			return true
+		}
+
+		var m outboxMessage
+		if err := json.Unmarshal([]byte(raw), &m); err != nil {
+			logger.Log.Warnf("outbox: dropping malformed message of chat %d: %s", chatID, raw)
+			getRedis().LPop(ctx, key)
+			continue
+		}
+		if m.Kind == outboxForget {
+			o.sender.Forget(m.ChatID, m.Key)
+			getRedis().LPop(ctx, key)
+			continue
+		}
+
+		now := time.Now()
+		if !c.bucket.take(now) {
+			return true
+		}
+		if !o.global.take(now) {
+			c.bucket.refund()
+			return false
+		}
+
+		err = o.send(m)
+		if err == nil {
+			getRedis().LPop(ctx, key)
+			continue
+		}
+
+		m.Attempts++
+		delay, retry := o.retryDelay(err, m.Attempts)
+		if !retry || m.Attempts >= o.cfg.MaxAttempts {
+			logger.Log.WithError(err).Warnf("outbox: dropping message to chat %d after %d attempts", chatID, m.Attempts)
+			metrics.OutboxDropped.Inc()
+			if m.Kind == outboxClosed {
+				o.sender.Forget(m.ChatID, m.Key) // иначе следующий сигнал правил бы старое сообщение
+			}
+			getRedis().LPop(ctx, key)
+			continue
+		}
+		logger.Log.WithError(err).Infof("outbox: chat %d, attempt %d failed, retry in %v", chatID, m.Attempts, delay)
+		metrics.OutboxRetries.Inc()
+		if data, err := json.Marshal(m); err == nil {
+			getRedis().LSet(ctx, key, 0, data)
+		}
+		c.retryAt = now.Add(delay)
+		if throttled(err) {
+			// лимит бота: остальные чаты тоже ждут retry_after
+			o.pausedUntil = c.retryAt
+			return false
+		}
+		return true
+	}
+}
+
+func (o *Outbox) send(m outboxMessage) error {
+	start := time.Now()
+	var err error
+	if m.Kind == outboxClosed {
+		err = o.sender.Closed(m.ChatID, m.Key, m.Text)
+	} else {
+		err = o.sender.Signal(m.ChatID, m.Key, m.Text)
+	}
+	metrics.ObserveSend(start, err)
+	return err
+}
+
+// retryDelay returns when to try a failed message again and whether it is
+// worth it: Telegram's retry_after when it sends one, doubling backoff
+// otherwise. Bad requests and blocked bots are not retried.
+func (o *Outbox) retryDelay(err error, attempts int) (time.Duration, bool) {
+	var tgErr *tgbotapi.Error
+	if errors.As(err, &tgErr) {
+		if tgErr.RetryAfter > 0 {
+			return time.Duration(tgErr.RetryAfter) * time.Second, true
+		}
+		if tgErr.Code == 400 || tgErr.Code == 403 {
+			return 0, false
+		}
+	}
+	d := o.cfg.Backoff << (attempts - 1)
+	if d <= 0 || d > o.cfg.MaxBackoff {
+		d = o.cfg.MaxBackoff
+	}
+	return d, true
+}
+
+// throttled reports whether Telegram asked the bot to slow down (429 with
+// retry_after).
+func throttled(err error) bool {
+	var tgErr *tgbotapi.Error
+	return errors.As(err, &tgErr) && tgErr.RetryAfter > 0
+}
+
+// forgetChat takes a chat with no messages out of outbox:chats. A message
+// pushed meanwhile puts it back: enqueue adds the chat after the message.
+func (o *Outbox) forgetChat(ctx context.Context, chatID int64) {
+	c := getRedis()
+	if err := c.SRem(ctx, outboxChatsKey, chatID).Err(); err != nil {
+		return
+	}
+	if n, err := c.LLen(ctx, outboxChatKey(chatID)).Result(); err == nil && n > 0 {
+		c.SAdd(ctx, outboxChatsKey, chatID)
+	}
+}
+
+// tokenBucket allows rate events per second with bursts up to burst.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+func (b *tokenBucket) take(now time.Time) bool {
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (b *tokenBucket) refund() {
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
@@ -3,8 +3,6 @@ package redisqueue
 import (
 	"context"
 	"fmt"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
@@ -15,15 +13,20 @@ import (
 
 const JobQueueKey = "jobs:queue"
 
-func EnqueueJob(job string) error {
+func EnqueueJob(job *Job) error {
+	payload, err := job.Encode()
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := RedisClient.RPush(ctx, JobQueueKey, job).Err(); err != nil {
+	if err := getJobQueue().push(ctx, payload); err != nil {
 		logger.Log.Errorf("failed to enqueue job: %v", err)
 		return err
 	}
-	logger.Log.Infof("Enqueued job: %s", job)
+	logger.Log.Infof("Enqueued job: %s", payload)
 	return nil
 }
 
@@ -33,7 +36,7 @@ func StartAnalysisForUser(bot *tgbotapi.BotAPI, chatID int64, userState *domain.
 		logger.Log.Errorf("failed to set user state: %v", err)
 		return err
 	}
-	job := fmt.Sprintf("detect-as:%.2f:%.2f:%d", userState.MinDiff, userState.MaxSum, chatID)
+	job := NewDetectJob(chatID, userState.MinDiff, userState.MaxSum)
 	if err := EnqueueJob(job); err != nil {
 		logger.Log.Errorf("failed to enqueue job: %v", err)
 		return err
@@ -41,6 +44,21 @@ func StartAnalysisForUser(bot *tgbotapi.BotAPI, chatID int64, userState *domain.
 	return nil
 }
 
+// UpdateAnalysisParams pushes new parameters to the running analysis of a
+// chat without restarting it: to the local worker right away, and through
+// leases:chats to the instance that owns the chat. Call it only while the
+// analysis is on, or the chat would be taken over and started.
+func UpdateAnalysisParams(chatID int64, minDiff, maxSum float64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := saveLeaseParams(ctx, chatID, minDiff, maxSum); err != nil {
+		logger.Log.Errorf("failed to save lease params: %v", err)
+		return err
+	}
+	dispatcher.update(chatID, minDiff, maxSum)
+	return nil
+}
+
 func StopAnalysis(store db.UserStatesStore, chatID int64) error {
 	running := dispatcher.isRunning(chatID)
 
@@ -66,6 +84,12 @@ func StopAnalysis(store db.UserStatesStore, chatID int64) error {
 		logger.Log.Errorf("dispatcher.stop failed: %v", err)
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := dropLease(ctx, chatID); err != nil {
+		logger.Log.Errorf("failed to drop lease: %v", err)
+	}
+
 	_ = removeJobsForChat(chatID)
 
 	logger.Log.Infof("Analysis stopped for chatID %d (running=%v queued=%v prevStep=%s)", chatID, running, queued, step)
@@ -77,32 +101,37 @@ func hasJobsForChat(chatID int64) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	vals, err := RedisClient.LRange(ctx, JobQueueKey, 0, -1).Result()
+	queued, err := getJobQueue().list(ctx)
 	if err != nil {
 		return false, err
 	}
-	suffix := ":" + strconv.FormatInt(chatID, 10)
-	for _, v := range vals {
-		if strings.HasSuffix(v, suffix) {
+	for _, j := range queued {
+		if jobIsForChat(j.payload, chatID) {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
+// removeJobsForChat drops the chat's jobs, both waiting and taken but unacked.
 func removeJobsForChat(chatID int64) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	vals, err := RedisClient.LRange(ctx, JobQueueKey, 0, -1).Result()
+	q := getJobQueue()
+	queued, err := q.list(ctx)
 	if err != nil {
 		return err
 	}
-	suffix := ":" + strconv.FormatInt(chatID, 10)
-	for _, v := range vals {
-		if strings.HasSuffix(v, suffix) {
-			_ = RedisClient.LRem(ctx, JobQueueKey, 0, v).Err()
+	for _, j := range queued {
+		if jobIsForChat(j.payload, chatID) {
+			_ = q.remove(ctx, j)
 		}
 	}
 	return nil
 }
+
+func jobIsForChat(raw string, chatID int64) bool {
+	j, err := DecodeJob(raw)
+	return err == nil && j.ChatID == chatID
+}
@@ -0,0 +1,162 @@
+package redisqueue
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	JobStreamKey = "jobs:stream"
+	jobGroup     = "workers"
+
+	// claimIdle — через сколько неподтвержденную запись забирает другой консьюмер
+	claimIdle  = 60 * time.Second
+	claimBatch = 10
+)
+
+// streamQueue keeps jobs in a Redis stream read by one consumer group, so
+// every job goes to one instance. Acked entries are deleted from the stream;
+// entries pending on a consumer for longer than claimIdle are claimed by the
+// others with XAUTOCLAIM.
+type streamQueue struct {
+	id string
+
+	mu      sync.Mutex
+	claimed []redis.XMessage
+}
+
+func newStreamQueue(id string) *streamQueue {
+	q := &streamQueue{id: id}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := q.ensureGroup(ctx); err != nil {
+		logger.Log.WithError(err).Warn("failed to create job consumer group")
+	}
+	logger.Log.Infof("Streams job queue: consumer %s", id)
+	return q
+}
+
+func (q *streamQueue) ensureGroup(ctx context.Context) error {
+	err := getRedis().XGroupCreateMkStream(ctx, JobStreamKey, jobGroup, "0").Err()
+	if err != nil && strings.Contains(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+func (q *streamQueue) push(ctx context.Context, payload string) error {
+	return getRedis().XAdd(ctx, &redis.XAddArgs{
+		Stream: JobStreamKey,
+		Values: map[string]any{"job": payload},
+	}).Err()
+}
+
+func (q *streamQueue) pop(ctx context.Context) (*delivery, error) {
+	q.mu.Lock()
+	if len(q.claimed) > 0 {
+		msg := q.claimed[0]
+		q.claimed = q.claimed[1:]
+		q.mu.Unlock()
+		return streamDelivery(msg), nil
+	}
+	q.mu.Unlock()
+
+	res, err := getRedis().XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    jobGroup,
+		Consumer: q.id,
+		Streams:  []string{JobStreamKey, ">"},
+		Count:    1,
+		Block:    popTimeout,
+	}).Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "NOGROUP") {
+			_ = q.ensureGroup(ctx)
+		}
+		return nil, err
+	}
+	for _, st := range res {
+		for _, msg := range st.Messages {
+			return streamDelivery(msg), nil
+		}
+	}
+	return nil, redis.Nil
+}
+
+func streamDelivery(msg redis.XMessage) *delivery {
+	payload, _ := msg.Values["job"].(string)
+	return &delivery{payload: payload, id: msg.ID}
+}
+
+func (q *streamQueue) ack(ctx context.Context, d *delivery) error {
+	c := getRedis()
+	if err := c.XAck(ctx, JobStreamKey, jobGroup, d.id).Err(); err != nil {
+		return err
+	}
+	return c.XDel(ctx, JobStreamKey, d.id).Err()
+}
+
+// maintain claims the entries other consumers took but did not ack in time.
+func (q *streamQueue) maintain(ctx context.Context) {
+	msgs, _, err := getRedis().XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   JobStreamKey,
+		Group:    jobGroup,
+		Consumer: q.id,
+		MinIdle:  claimIdle,
+		Start:    "0-0",
+		Count:    claimBatch,
+	}).Result()
+	if err != nil {
+		logger.Log.WithError(err).Warn("XAUTOCLAIM failed")
+		return
+	}
+	if len(msgs) == 0 {
+		return
+	}
+	logger.Log.Warnf("Claimed %d pending jobs of other consumers", len(msgs))
+
+	q.mu.Lock()
+	q.claimed = append(q.claimed, msgs...)
+	q.mu.Unlock()
+}
+
+// release leaves our pending entries to be claimed by another instance.
+func (q *streamQueue) release(context.Context) error {
+	q.mu.Lock()
+	n := len(q.claimed)
+	q.mu.Unlock()
+	if n > 0 {
+		logger.Log.Infof("%d claimed jobs left pending for other consumers", n)
+	}
+	return nil
+}
+
+// list returns every entry still in the stream: acked ones are deleted, so
+// these are waiting or pending.
+func (q *streamQueue) list(ctx context.Context) ([]queuedJob, error) {
+	msgs, err := getRedis().XRange(ctx, JobStreamKey, "-", "+").Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]queuedJob, 0, len(msgs))
+	for _, msg := range msgs {
+		d := streamDelivery(msg)
+		out = append(out, queuedJob{payload: d.payload, id: d.id})
+	}
+	return out, nil
+}
+
+func (q *streamQueue) remove(ctx context.Context, j queuedJob) error {
+	c := getRedis()
+	_ = c.XAck(ctx, JobStreamKey, jobGroup, j.id).Err()
+	return c.XDel(ctx, JobStreamKey, j.id).Err()
+}
+
+func (q *streamQueue) length(ctx context.Context) (int64, error) {
+	return getRedis().XLen(ctx, JobStreamKey).Result()
+}
@@ -2,15 +2,21 @@ package redisqueue
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/Shyyw1e/arbitrage-sync/internal/config"
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
 	"github.com/Shyyw1e/arbitrage-sync/internal/core/usecase"
 	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/db"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/marketdata"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/metrics"
 	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/redis/go-redis/v9"
@@ -39,9 +45,18 @@ type cmd struct {
 	min float64
 	max float64
 	bot *tgbotapi.BotAPI
+	// timing, если задан, в cmdUpdate меняет только интервалы анализа
+	timing *workerTiming
+	// userTick, если задан, в cmdUpdate меняет только интервал чата
+	userTick *time.Duration
 	reply chan error
 }
 
+type workerTiming struct {
+	gap      time.Duration
+	fallback time.Duration
+}
+
 type worker struct {
 	chatID 		int64
 	cmdCh  		chan cmd
@@ -50,6 +65,10 @@ type worker struct {
 	max			atomic.Value
 	bot 		atomic.Value		//tgbotapi.BotAPI
 	hb 			atomic.Value		//time.Time (lastTick)
+	userTick	atomic.Int64		//time.Duration, 0 — глобальный интервал
+	tracker		*usecase.LifecycleTracker	// только из run
+	live		map[string]string	// ключ -> текст живого сообщения, только из run
+	closing		map[string]string	// ключ -> текст закрытия, ждущий конца паузы, только из run
 }
 
 func (w *worker) getMin() float64            { v, _ := w.min.Load().(float64); return v }
@@ -60,13 +79,125 @@ func (w *worker) setHB(t time.Time)          { w.hb.Store(t) }
 func (w *worker) isRunning() bool            { return w.running.Load() }
 func (w *worker) setRunning(v bool)          { w.running.Store(v) }
 
+// gap is the minimum interval between two analyses: the chat's own tick
+// interval or the global one.
+func (w *worker) gap(t workerTiming) time.Duration {
+	if d := time.Duration(w.userTick.Load()); d > 0 {
+		return d
+	}
+	return t.gap
+}
+
+var (
+	// staleAfter — после такого времени без HB watchdog перезапускает воркер
+	staleAfter = 90 * time.Second
+	watchdogInterval = 15 * time.Second
+
+	timingMu sync.RWMutex
+	// fallbackTick запускает анализ, даже если стаканы давно не менялись
+	fallbackTick = 30 * time.Second
+	// minAnalysisGap не дает пачке событий хаба запускать анализ слишком часто
+	minAnalysisGap = 2 * time.Second
+)
+
+// ConfigureWorkers sets the worker timings; call it before StartWorkerLoop.
+func ConfigureWorkers(cfg config.Worker) {
+	staleAfter = cfg.StaleAfter
+	watchdogInterval = cfg.WatchdogInterval
+
+	timingMu.Lock()
+	minAnalysisGap, fallbackTick = cfg.TickInterval, cfg.FallbackTick
+	timingMu.Unlock()
+}
+
+func currentTiming() workerTiming {
+	timingMu.RLock()
+	defer timingMu.RUnlock()
+	return workerTiming{gap: minAnalysisGap, fallback: fallbackTick}
+}
+
+// SetChatTickInterval pushes the chat's own tick interval (0 — the global
+// one) to its worker, if this instance runs it.
+func SetChatTickInterval(chatID int64, d time.Duration) {
+	dispatcher.mu.Lock()
+	w, ok := dispatcher.workers[chatID]
+	dispatcher.mu.Unlock()
+	if !ok {
+		return
+	}
+	// не дошла — tick и так читает интервал чата из user_states
+	w.trySend(cmd{typ: cmdUpdate, userTick: &d})
+}
+
+// SetWorkerTiming changes the analysis intervals and pushes them to every
+// worker with cmdUpdate; running workers keep their chat and parameters.
+func SetWorkerTiming(tick, fallback time.Duration) {
+	timingMu.Lock()
+	minAnalysisGap, fallbackTick = tick, fallback
+	timingMu.Unlock()
+
+	t := workerTiming{gap: tick, fallback: fallback}
+	for _, w := range dispatcher.list() {
+		// не дошла — воркер сверит интервалы на fallback tick
+		w.trySend(cmd{typ: cmdUpdate, timing: &t})
+	}
+}
+
+// cmdSendTimeout bounds how long a config reload or a Telegram handler
+// waits for a busy worker to take an update.
+const cmdSendTimeout = time.Second
+
+// trySend hands c to the worker unless its command buffer stays full for
+// cmdSendTimeout, e.g. during a long tick; the update is then dropped.
+func (w *worker) trySend(c cmd) bool {
+	t := time.NewTimer(cmdSendTimeout)
+	defer t.Stop()
+	select {
+	case w.cmdCh <- c:
+		return true
+	case <-t.C:
+		logger.Log.Warnf("worker %d: command queue is full, update dropped", w.chatID)
+		return false
+	}
+}
+
+// run serves commands and, while running, analyses the books every time the
+// market-data hub reports a change (at most once per tick interval) and at
+// least once per fallback tick.
 func (w *worker) run(store db.UserStatesStore) {
 	var (
-		ticker *time.Ticker
-		tickC <-chan time.Time
-		//cancel context.CancelFunc
+		ticker    *time.Ticker
+		tickC     <-chan time.Time
+		events    <-chan marketdata.BookEvent
+		unsub     func()
+		debounce  *time.Timer
+		debounceC <-chan time.Time
+		lastRun   time.Time
+		timing    = currentTiming()
 	)
 
+	stopRunning := func() {
+		w.setRunning(false)
+		if ticker != nil {
+			ticker.Stop()
+		}
+		tickC = nil
+		if unsub != nil {
+			unsub()
+			unsub = nil
+		}
+		events = nil
+		if debounce != nil {
+			debounce.Stop()
+		}
+		debounceC = nil
+	}
+
+	analyze := func() {
+		lastRun = time.Now()
+		w.tick(store)
+	}
+
 	for {
 		select {
 		case c := <-w.cmdCh:
@@ -80,14 +211,34 @@ func (w *worker) run(store db.UserStatesStore) {
 
 				if !w.isRunning() {
 					logger.Log.Infof("starting worker %d", w.chatID)
-					ticker = time.NewTicker(20*time.Second)
+					ticker = time.NewTicker(timing.fallback)
 					tickC = ticker.C
+					events, unsub = marketdata.DefaultHub.Subscribe()
 					w.setRunning(true)
 				}
 				if c.reply != nil {
 					c.reply <- nil
 				}
 			case cmdUpdate:
+				if c.timing != nil {
+					logger.Log.Infof("worker %d: tick interval %v, fallback %v", w.chatID, c.timing.gap, c.timing.fallback)
+					timing = *c.timing
+					if w.isRunning() {
+						ticker.Reset(timing.fallback)
+					}
+					if c.reply != nil {
+						c.reply <- nil
+					}
+					continue
+				}
+				if c.userTick != nil {
+					logger.Log.Infof("worker %d: chat tick interval %v", w.chatID, *c.userTick)
+					w.userTick.Store(int64(*c.userTick))
+					if c.reply != nil {
+						c.reply <- nil
+					}
+					continue
+				}
 				logger.Log.Infof("updating worker %d", w.chatID)
 				w.min.Store(c.min)
 				w.max.Store(c.max)
@@ -101,13 +252,12 @@ func (w *worker) run(store db.UserStatesStore) {
 
 			case cmdStop:
 				if w.isRunning() {
-					w.setRunning(false)
 					logger.Log.Infof("stopping worker %d", w.chatID)
-					if ticker != nil {
-						ticker.Stop()
-					}
-					tickC = nil
+					stopRunning()
 				}
+				// открытые жизненные циклы остаются в базе, при старте
+				// закроются по maxGap или продолжатся
+				w.tracker = nil
 				w.hb.Store(time.Time{})
 				if c.reply != nil {
 					c.reply <- nil
@@ -115,92 +265,300 @@ func (w *worker) run(store db.UserStatesStore) {
 
 			case cmdShutdown:
 				if w.isRunning() {
-					w.setRunning(false)
-					if ticker != nil {
-						ticker.Stop()
-					}
-					tickC = nil
-					// if cancel != nil {
-					// 	cancel()
-					// }
+					stopRunning()
 				}
 				if c.reply != nil {
 					c.reply<-nil
 				}
 				return
 			}
-		case <-tickC:
-			st, err := store.Get(w.chatID)
-			if err != nil {
-				logger.Log.WithError(err).Warnf("worker %d: failed to get userStore", w.chatID)
+		case <-events:
+			if wait := w.gap(timing) - time.Since(lastRun); wait > 0 {
+				if debounceC == nil {
+					debounce = time.NewTimer(wait)
+					debounceC = debounce.C
+				}
 				continue
 			}
-			if st == nil || st.Step != "ready_to_run" {
-				step := "<nil>"
-				if st != nil { step = st.Step }
-				logger.Log.Warnf("worker %d: inactive user state (step=%s)", w.chatID, step)
+			analyze()
+		case <-debounceC:
+			debounceC = nil
+			analyze()
+		case <-tickC:
+			if t := currentTiming(); t != timing {
+				// cmdUpdate с интервалами мог не дойти (trySend)
+				timing = t
+				ticker.Reset(timing.fallback)
+			}
+			if time.Since(lastRun) < w.gap(timing) {
+				// интервал чата длиннее fallback tick: воркер жив, просто ждет,
+				// иначе watchdog счел бы его зависшим
+				w.setHB(time.Now())
 				continue
 			}
+			analyze()
+		}
+	}
+}
 
+// tick runs every detector once and sends the found signals.
+func (w *worker) tick(store db.UserStatesStore) {
+	st, err := store.Get(w.chatID)
+	if err != nil {
+		logger.Log.WithError(err).Warnf("worker %d: failed to get userStore", w.chatID)
+		return
+	}
+	if st == nil || st.Step != "ready_to_run" {
+		step := "<nil>"
+		if st != nil { step = st.Step }
+		logger.Log.Warnf("worker %d: inactive user state (step=%s)", w.chatID, step)
+		return
+	}
 
-			min, max := w.getMin(), w.getMax()
-			bot := w.getBot()
-			if bot == nil {
-				logger.Log.Warnf("worker %d: bot is nil, skip tick", w.chatID)
-				continue
+
+	w.userTick.Store(int64(st.Schedule.TickInterval))
+
+	min, max := w.getMin(), w.getMax()
+	if notifier == nil {
+		logger.Log.Warnf("worker %d: notifier is nil, skip tick", w.chatID)
+		return
+	}
+
+	// один снимок стаканов на тик: все детекторы видят одни и те же книги
+	books := usecase.CurrentBooks()
+	if len(books) == 0 {
+		logger.Log.Warnf("worker %d: no fresh order books, skip tick", w.chatID)
+		w.setHB(time.Now())
+		return
+	}
+
+	// в тихие часы и неактивные дни анализ и журнал идут, сообщения — нет
+	paused := st.Schedule.Paused(time.Now())
+	if paused {
+		logger.Log.Debugf("worker %d: notifications paused by schedule", w.chatID)
+	}
+
+	// сигналы, закрывшиеся в паузу, показываем закрытыми, когда она кончилась
+	if !paused {
+		for key, text := range w.closing {
+			w.closed(key, text)
+		}
+		clear(w.closing)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	sent := loadDedup(ctx, w.chatID)
+	cancel()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		sent.save(ctx)
+	}()
+
+	// notify shows a signal unless the schedule pauses it. A signal with a
+	// live message edits it whenever its text changes; deduplication only
+	// decides whether to send a new message.
+	notify := func(key string, margin float64, text string) {
+		if paused {
+			return
+		}
+		if last, ok := w.live[key]; ok {
+			// правка не будит чат, темп держат лимиты outbox
+			if text != last && w.signal(key, text) {
+				w.live[key] = text
 			}
+			return
+		}
+		now := time.Now()
+		if !sent.allow(key, margin, now) {
+			metrics.SignalsDeduplicated.Inc()
+			return
+		}
+		if w.signal(key, text) {
+			sent.mark(key, margin, now)
+			w.live[key] = text
+		}
+	}
 
-			facts, err := usecase.DetectFact(min, max, w.chatID)
-			if err != nil {
-				logger.Log.WithError(err).Warnf("worker %d: DetectFact failed, skip tick", w.chatID)
+	now := time.Now()
+	lc := w.lifecycleTracker(w.lifecycleMaxGap())
+	var touched []*domain.Lifecycle
+
+	// report follows the signals of one detector through their lifecycles:
+	// each is sent with its lifecycle note, the ones of typ that are gone
+	// get a closing message if the chat was alerted about them. Signals on
+	// venues the chat turned off count as gone.
+	report := func(typ domain.SignalType, signals []signal) {
+		kept := signals[:0]
+		for _, s := range signals {
+			if st.Filters.SourcesEnabled(s.venues...) {
+				kept = append(kept, s)
+			}
+		}
+		signals = kept
+
+		if lc == nil {
+			for _, s := range signals {
+				notify(s.obs.Key, s.obs.Margin, s.text)
+			}
+			return
+		}
+		obs := make([]usecase.Observation, 0, len(signals))
+		for _, s := range signals {
+			obs = append(obs, s.obs)
+		}
+		events := make(map[string]domain.LifecycleEvent)
+		for _, ch := range lc.Observe(now, typ, obs) {
+			l := ch.Lifecycle
+			metrics.LifecycleEvents.WithLabelValues(string(ch.Event)).Inc()
+			logger.Log.Debugf("worker %d: %s %s, margin %.4f, %v", w.chatID, ch.Event, l.Key, l.LastMargin, l.Duration())
+			if ch.Event != domain.LifecycleClosed {
+				events[l.Key] = ch.Event
 				continue
 			}
-			if len(facts) > 0 {
-				for _, op := range facts {
-					text := fmt.Sprintf("💰 Найден фактический арбитраж!\nBuy %s @ %.2f\nSell %s @ %.2f\nProfit: %.2f",
-						op.BuyExchange, op.BuyPrice, op.SellExchange, op.SellPrice, op.ProfitMargin)
-					_, err = bot.Send(tgbotapi.NewMessage(w.chatID, text))
-					if err != nil {
-						logger.Log.WithError(err).Warnf("worker %d: failed to send message", w.chatID)
-					}
-					time.Sleep(1500 * time.Millisecond)
-				}
+			touched = append(touched, l)
+			_, live := w.live[l.Key]
+			switch {
+			case (live || sent.alerted(l.Key)) && !paused:
+				w.closed(l.Key, closedText(l))
+			case live || sent.alerted(l.Key):
+				// id сообщения не бросаем, закроем его после паузы
+				delete(w.live, l.Key)
+				w.closing[l.Key] = closedText(l)
+			default:
+				// сообщение о нем могло остаться с прошлого запуска
+				w.forget(l.Key)
+			}
+			sent.forget(l.Key)
+		}
+		for _, s := range signals {
+			ev := events[s.obs.Key]
+			_, closing := w.closing[s.obs.Key]
+			if ev == domain.LifecycleOpened && !closing {
+				w.forget(s.obs.Key) // новая возможность — новое сообщение, а не правка старого
 			}
+			note := lifecycleNote(ev, lc.Lifecycle(s.obs.Key))
+			notify(s.obs.Key, s.obs.Margin, s.text+note)
+		}
+	}
 
-			ops, pots, err := usecase.DetectAS(min, max, w.chatID)
-			if err != nil {
-				logger.Log.WithError(err).Warnf("worker %d: DetectAS failed", w.chatID)
-				goto afterTick
+	// выключенные в настройках типы сигналов не считаем вовсе, а их открытые
+	// сигналы закрываем, как пропавшие
+	enabled := st.Filters.SignalEnabled
+	for _, t := range domain.SignalTypes {
+		if !enabled(t) {
+			report(t, nil)
+		}
+	}
+	var signals []signal
+
+	if enabled(domain.SignalFact) {
+		facts, err := usecase.DetectFactFromBooks(books, min, max)
+		if err != nil {
+			logger.Log.WithError(err).Warnf("worker %d: DetectFact failed, skip tick", w.chatID)
+			return
+		}
+		recordOpportunities(w.chatID, domain.SignalFact, facts)
+		for _, op := range facts {
+			text := fmt.Sprintf("💰 Найден фактический арбитраж!\nBuy %s @ %.2f\nSell %s @ %.2f\nProfit: %.2f",
+				op.BuyExchange, op.BuyPrice, op.SellExchange, op.SellPrice, op.ProfitMargin)
+			signals = append(signals, pairSignal(domain.SignalFact, op, text))
+		}
+		report(domain.SignalFact, signals)
+	}
+
+	if enabled(domain.SignalDepth) {
+		depth, err := usecase.DetectDepthFromBooks(books, min, max)
+		if err != nil {
+			logger.Log.WithError(err).Warnf("worker %d: DetectDepth failed", w.chatID)
+		} else {
+			recordOpportunities(w.chatID, domain.SignalDepth, depth)
+			signals = signals[:0]
+			for _, op := range depth {
+				text := fmt.Sprintf("📊 Исполнимый арбитраж по стакану!\nBuy %s @ %.2f (VWAP)\nSell %s @ %.2f (VWAP)\nОбъем: %.2f\nProfit: %.2f RUB (%.3f%%)",
+					op.BuyExchange, op.BuyPrice, op.SellExchange, op.SellPrice, op.Volume, op.TotalProfit, op.ProfitPercent)
+				signals = append(signals, pairSignal(domain.SignalDepth, op, text))
+			}
+			report(domain.SignalDepth, signals)
+		}
+	}
+
+	if enabled(domain.SignalTriangle) {
+		triangles, err := usecase.DetectTriangleFromBooks(books, min, max)
+		if err != nil {
+			logger.Log.WithError(err).Warnf("worker %d: DetectTriangle failed", w.chatID)
+		} else {
+			recordTriangles(w.chatID, triangles)
+			signals = signals[:0]
+			for _, op := range triangles {
+				var legs strings.Builder
+				for _, l := range op.Legs {
+					fmt.Fprintf(&legs, "%s → %s: %s @ %.4f\n", l.From, l.To, l.Source, l.Price)
+				}
+				text := fmt.Sprintf("🔺 Найден треугольный арбитраж!\n%sВход: %.2f RUB\nВыход: %.2f RUB\nProfit: %.2f RUB (%.3f%%)",
+					legs.String(), op.StartAmount, op.EndAmount, op.TotalProfit, op.ProfitPercent)
+				signals = append(signals, triangleSignal(op, text))
 			}
-			if len(ops) > 0 {
+			report(domain.SignalTriangle, signals)
+		}
+	}
+
+	if enabled(domain.SignalPotential) || enabled(domain.SignalReverse) {
+		ops, pots, err := usecase.DetectASFromBooks(books, min, max)
+		if err != nil {
+			logger.Log.WithError(err).Warnf("worker %d: DetectAS failed", w.chatID)
+		} else {
+			recordOpportunities(w.chatID, domain.SignalPotential, ops)
+			recordOpportunities(w.chatID, domain.SignalReverse, pots)
+			if enabled(domain.SignalPotential) {
+				signals = signals[:0]
 				for _, op := range ops {
 					text := fmt.Sprintf("💰 Найден потенциальный арбитраж!\nBuy %s @ %.2f\nSell %s @ %.2f\nProfit: %.2f",
 						op.BuyExchange, op.BuyPrice, op.SellExchange, op.SellPrice, op.ProfitMargin)
-					_, err = bot.Send(tgbotapi.NewMessage(w.chatID, text))
-					if err != nil {
-						logger.Log.WithError(err).Warnf("worker %d: failed to send message", w.chatID)
-					}
-					time.Sleep(1500 * time.Millisecond)
+					signals = append(signals, pairSignal(domain.SignalPotential, op, text))
 				}
+				report(domain.SignalPotential, signals)
 			}
-			if len(pots) > 0 {
+			if enabled(domain.SignalReverse) {
+				signals = signals[:0]
 				for _, op := range pots {
 					text := fmt.Sprintf("💰 Найден обратный потенциальный арбитраж!\nBuy %s @ %.2f\nSell %s @ %.2f\nProfit: %.2f",
 						op.BuyExchange, op.BuyPrice, op.SellExchange, op.SellPrice, op.ProfitMargin)
-					_, err = bot.Send(tgbotapi.NewMessage(w.chatID, text))
-					if err != nil {
-						logger.Log.WithError(err).Warnf("worker %d: failed to send message", w.chatID)
-					}
-					time.Sleep(1500 * time.Millisecond)
+					signals = append(signals, pairSignal(domain.SignalReverse, op, text))
 				}
+				report(domain.SignalReverse, signals)
 			}
+		}
+	}
 
-			afterTick:
-			// HB только после завершения тика (чтобы watchdog не трогал долгие парсы)
-			w.setHB(time.Now())
+	if lc != nil {
+		saveLifecycles(w.chatID, append(touched, lc.Open()...))
+	}
 
+	// HB только после завершения тика (чтобы watchdog не трогал долгие парсы)
+	w.setHB(time.Now())
+}
 
-		}
+// signal reports whether the signal's message was queued.
+func (w *worker) signal(key, text string) bool {
+	if err := notifier.Signal(w.chatID, key, text); err != nil {
+		logger.Log.WithError(err).Warnf("worker %d: failed to queue message", w.chatID)
+		return false
+	}
+	return true
+}
+
+func (w *worker) closed(key, text string) {
+	delete(w.live, key)
+	if err := notifier.Closed(w.chatID, key, text); err != nil {
+		logger.Log.WithError(err).Warnf("worker %d: failed to queue message", w.chatID)
+	}
+}
+
+func (w *worker) forget(key string) {
+	delete(w.live, key)
+	if err := notifier.Forget(w.chatID, key); err != nil {
+		logger.Log.WithError(err).Warnf("worker %d: failed to queue message", w.chatID)
 	}
 }
 
@@ -211,8 +569,11 @@ func (w *worker) run(store db.UserStatesStore) {
 type dispatcherT struct {
 	mu 			sync.Mutex
 	workers 	map[int64]*worker
+	closed		bool
 }
 
+var errDispatcherClosed = errors.New("dispatcher is shut down")
+
 func newDispatcher() *dispatcherT {
 	return &dispatcherT{workers: make(map[int64]*worker)}
 }
@@ -221,6 +582,9 @@ func (d *dispatcherT) ensure(chatID int64, store db.UserStatesStore) *worker {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	if d.closed {
+		return nil
+	}
 	if w, ok := d.workers[chatID]; ok {
 		logger.Log.Infof("Worker %d: ensured", chatID)
 		return w
@@ -228,6 +592,8 @@ func (d *dispatcherT) ensure(chatID int64, store db.UserStatesStore) *worker {
 
 	w := &worker{chatID: chatID,
 		cmdCh: make(chan cmd, 16),
+		live:    make(map[string]string),
+		closing: make(map[string]string),
 	}
 
 	w.hb.Store(time.Time{})
@@ -238,13 +604,39 @@ func (d *dispatcherT) ensure(chatID int64, store db.UserStatesStore) *worker {
 	return w
 }
 
+// start saves the chat's parameters for handover, takes the chat's lease and
+// starts its worker. It fails with errLeaseHeld if another instance runs it.
 func (d *dispatcherT) start(chatID int64, min, max float64, bot *tgbotapi.BotAPI, store db.UserStatesStore) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := saveLeaseParams(ctx, chatID, min, max); err != nil {
+		return fmt.Errorf("save lease params: %w", err)
+	}
+	if err := acquireLease(ctx, chatID); err != nil {
+		return err
+	}
+
 	w := d.ensure(chatID, store)
+	if w == nil {
+		return errDispatcherClosed
+	}
 	reply := make(chan error, 1)
 	w.cmdCh<-cmd{typ: cmdStart, min: min, max: max, bot: bot, reply: reply}
 	return <-reply
 }
 
+// update pushes new parameters to a running worker without restarting it.
+func (d *dispatcherT) update(chatID int64, min, max float64) {
+	d.mu.Lock()
+	w, ok := d.workers[chatID]
+	d.mu.Unlock()
+	if !ok {
+		return
+	}
+	// не дошла — watchdog повторит по leases:chats
+	w.trySend(cmd{typ: cmdUpdate, min: min, max: max})
+}
+
 func (d *dispatcherT) stop(chatID int64, _ db.UserStatesStore) error {
 	d.mu.Lock()
 	w, ok := d.workers[chatID]
@@ -269,6 +661,56 @@ func (d *dispatcherT) isRunning(chatID int64) bool {
 	return w.isRunning()
 }
 
+// shutdown stops accepting new workers and sends cmdShutdown to every
+// existing one. A worker in the middle of a tick finishes it (and its sends)
+// first. Workers that were running release their lease, so another instance
+// (or this one after restart) takes the chat over from leases:chats.
+func (d *dispatcherT) shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	ws := d.workers
+	d.workers = make(map[int64]*worker)
+	d.mu.Unlock()
+
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Int32
+	)
+	for _, w := range ws {
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+
+			wasRunning := w.isRunning()
+			reply := make(chan error, 1)
+			select {
+			case w.cmdCh <- cmd{typ: cmdShutdown, reply: reply}:
+			case <-ctx.Done():
+				failed.Add(1)
+				return
+			}
+			select {
+			case <-reply:
+			case <-ctx.Done():
+				failed.Add(1)
+				return
+			}
+
+			if wasRunning {
+				if err := releaseLease(ctx, w.chatID); err != nil {
+					logger.Log.WithError(err).Warnf("worker %d: failed to release lease", w.chatID)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if n := failed.Load(); n > 0 {
+		return fmt.Errorf("%d workers did not stop before deadline: %w", n, ctx.Err())
+	}
+	return nil
+}
+
 func (d *dispatcherT) list() []*worker {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -279,70 +721,73 @@ func (d *dispatcherT) list() []*worker {
 	return out
 }
 
-func StartWorkerLoop(bot *tgbotapi.BotAPI) {
+// loops tracks the consumer and watchdog goroutines of StartWorkerLoop and
+// the delivery goroutine of the Outbox.
+var loops sync.WaitGroup
+
+// StartWorkerLoop consumes jobs and watches worker heartbeats until ctx is done.
+func StartWorkerLoop(ctx context.Context, bot *tgbotapi.BotAPI) {
+	q := getJobQueue()
+
+	loops.Add(2)
 	go func ()  {
+		defer loops.Done()
 		for {
-			res, err := getRedis().BLPop(context.Background(), 10*time.Second, JobQueueKey).Result()
+			if ctx.Err() != nil {
+				logger.Log.Info("Job consumer stopped")
+				return
+			}
+			d, err := q.pop(ctx)
 			if err != nil {
+				if ctx.Err() != nil {
+					continue
+				}
 				if err == redis.Nil { // таймаут ожидания — норм
 					continue
 				}
 				if isReadOnlyErr(err) {
-					logger.Log.Warn("Redis returned READONLY on BLPOP — reconnecting...")
+					metrics.QueueErrors.Inc()
+					logger.Log.Warn("Redis returned READONLY on job pop — reconnecting...")
 					_ = resetRedisClient()
 					time.Sleep(2 * time.Second) // небольшой бэкофф
 					continue
 				}
-				logger.Log.Errorf("BLPOP error: %v", err)
+				metrics.QueueErrors.Inc()
+				logger.Log.Errorf("job pop error: %v", err)
 				time.Sleep(1 * time.Second)
 				continue
 			}
 
-			if len(res) < 2 {
+			if !handleJob(bot, d.payload) {
 				continue
 			}
-			job := res[1]
-
-			if !strings.HasPrefix(job, "detect-as:") {
-				logger.Log.Warnf("unknown job: %s", job)
-				continue
-			}
-
-			parts := strings.Split(job, ":")
-			if len(parts) != 4 {
-				logger.Log.Warnf("invalid job format: %s", job)
-				continue
-			}
-
-			min, err1 := strconv.ParseFloat(parts[1], 64)
-			max, err2 := strconv.ParseFloat(parts[2], 64)
-			chatID, err3 := strconv.ParseInt(parts[3], 10, 64)
-			if err1 != nil || err2 != nil || err3 != nil {
-				logger.Log.Warnf("job parse error: %v %v %v (%s)", err1, err2, err3, job)
-				continue
-			}
-			
-			st, _ := userStore.Get(chatID)
-			if st == nil || st.Step != "ready_to_run" {
-				step := "<nil>"
-				if st != nil { step = st.Step }
-				logger.Log.Warnf("worker %d: inactive user state (step=%s)", chatID, step)
-				continue
-			}
-
-			if err := dispatcher.start(chatID, min, max, bot, userStore); err != nil {
-				logger.Log.Errorf("dispatcher start failed for %d: %v", chatID, err)
+			ackCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := q.ack(ackCtx, d); err != nil {
+				logger.Log.WithError(err).Warnf("failed to ack job: %s", d.payload)
 			}
+			cancel()
 		}
 	}()
 
 	go func() {
-		t := time.NewTicker(15*time.Second)
+		defer loops.Done()
+		t := time.NewTicker(watchdogInterval)
 		defer t.Stop()
 
-		for range t.C {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
 			now := time.Now()
 			ws := dispatcher.list()
+			updateDispatcherMetrics(ws)
+
+			mctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			q.maintain(mctx)
+			maintainLeases(mctx, bot)
+			cancel()
 
 			for _, w := range ws {
 				if !w.isRunning() {
@@ -350,11 +795,12 @@ func StartWorkerLoop(bot *tgbotapi.BotAPI) {
 				}
 
 				hb := w.lastHB()
-				if hb.IsZero() || now.Sub(hb) <= 90*time.Second {
+				if hb.IsZero() || now.Sub(hb) <= staleAfter {
 					continue
 				}
 
 				logger.Log.Warnf("Watchdog: stale worker chat=%d lastHB=%v -> soft restart", w.chatID, hb)
+				metrics.WatchdogRestarts.Inc()
 
 				if err := dispatcher.stop(w.chatID, userStore); err != nil {
 					logger.Log.WithError(err).Warnf("Watchdog stop failed chat=%d", w.chatID)
@@ -371,4 +817,134 @@ func StartWorkerLoop(bot *tgbotapi.BotAPI) {
 			}
 		}
 	}()
-}
\ No newline at end of file
+}
+
+// updateDispatcherMetrics refreshes the worker and queue gauges, once per watchdog round.
+func updateDispatcherMetrics(ws []*worker) {
+	running := 0
+	for _, w := range ws {
+		if w.isRunning() {
+			running++
+		}
+	}
+	metrics.Workers.Set(float64(len(ws)))
+	metrics.RunningWorkers.Set(float64(running))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if n, err := getJobQueue().length(ctx); err == nil {
+		metrics.QueueLength.Set(float64(n))
+	}
+}
+
+// WorkerStatus is the heartbeat state of one dispatcher worker.
+type WorkerStatus struct {
+	ChatID       int64   `json:"chat_id"`
+	Running      bool    `json:"running"`
+	HeartbeatAge float64 `json:"heartbeat_age_seconds"`
+	Stale        bool    `json:"stale"`
+}
+
+// WorkerStatuses lists every worker with the age of its last heartbeat.
+// A worker that has not ticked yet reports age 0.
+func WorkerStatuses() []WorkerStatus {
+	now := time.Now()
+	ws := dispatcher.list()
+	out := make([]WorkerStatus, 0, len(ws))
+	for _, w := range ws {
+		st := WorkerStatus{ChatID: w.chatID, Running: w.isRunning()}
+		if hb := w.lastHB(); !hb.IsZero() {
+			age := now.Sub(hb)
+			st.HeartbeatAge = age.Seconds()
+			st.Stale = st.Running && age > staleAfter
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ChatID < out[j].ChatID })
+	return out
+}
+
+// CheckWorkers fails while some running worker has a stale heartbeat.
+func CheckWorkers() ([]WorkerStatus, error) {
+	ws := WorkerStatuses()
+	var stale []string
+	for _, w := range ws {
+		if w.Stale {
+			stale = append(stale, strconv.FormatInt(w.ChatID, 10))
+		}
+	}
+	if len(stale) > 0 {
+		return ws, fmt.Errorf("stale workers: %s", strings.Join(stale, ", "))
+	}
+	return ws, nil
+}
+
+// Shutdown waits for StartWorkerLoop and the Outbox to return (their ctx
+// must already be cancelled) and then stops every worker, giving up when ctx
+// is done. Undelivered messages stay in Redis for the next start.
+func Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		loops.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("worker loop did not stop: %w", ctx.Err())
+	}
+
+	err := dispatcher.shutdown(ctx)
+	if rerr := getJobQueue().release(ctx); rerr != nil {
+		logger.Log.WithError(rerr).Warn("failed to release unacked jobs")
+	}
+	return err
+}
+
+// handleJob starts the worker of a detection job. It reports whether the job
+// is done with and can be acked: malformed jobs, duplicates and inactive chats
+// are dropped, a job the dispatcher refused stays unacked.
+func handleJob(bot *tgbotapi.BotAPI, raw string) bool {
+	job, err := DecodeJob(raw)
+	if err != nil {
+		logger.Log.Warnf("dropping job: %v", err)
+		return true
+	}
+	if job.Version == 0 {
+		logger.Log.Infof("legacy job format for chat %d: %s", job.ChatID, raw)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	done := jobDone(ctx, job)
+	cancel()
+	if done {
+		logger.Log.Infof("job %s for chat %d already handled, skip", job.IdempotencyKey, job.ChatID)
+		return true
+	}
+
+	st, _ := userStore.Get(job.ChatID)
+	if st == nil || st.Step != "ready_to_run" {
+		step := "<nil>"
+		if st != nil { step = st.Step }
+		logger.Log.Warnf("worker %d: inactive user state (step=%s)", job.ChatID, step)
+		return true
+	}
+
+	err = dispatcher.start(job.ChatID, job.MinDiff, job.MaxSum, bot, userStore)
+	if errors.Is(err, errLeaseHeld) {
+		// чат ведет другой инстанс, новые параметры он возьмет из leases:chats
+		logger.Log.Infof("chat %d is owned by another instance, job handed over", job.ChatID)
+		return true
+	}
+	if err != nil {
+		logger.Log.Errorf("dispatcher start failed for %d: %v", job.ChatID, err)
+		return false
+	}
+	// свой таймаут: старт воркера мог съесть весь бюджет первого контекста
+	ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := markJobDone(ctx, job); err != nil {
+		logger.Log.WithError(err).Warnf("failed to mark job %s done", job.IdempotencyKey)
+	}
+	return true
+}
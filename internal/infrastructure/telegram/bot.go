@@ -1,8 +1,10 @@
 package telegram
 
 import (
+	"context"
+	"time"
 
-	
+	"github.com/Shyyw1e/arbitrage-sync/internal/config"
 	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
 	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/db"
 
@@ -10,13 +12,39 @@ import (
 )
 
 
+// pollTimeout is the long polling timeout of getUpdates.
+var pollTimeout = 10 * time.Second
 
-func StartBotWithBot(bot *tgbotapi.BotAPI, store db.UserStatesStore) {
+// Configure applies the telegram section of the config.
+func Configure(cfg config.Telegram) {
+	pollTimeout = cfg.PollTimeout
+	historyLimit = cfg.HistoryLimit
+}
+
+// StartBotWithBot handles updates until ctx is done, then stops long polling.
+// The update being handled is finished first.
+func StartBotWithBot(ctx context.Context, bot *tgbotapi.BotAPI, store db.UserStatesStore) {
 	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 10
+	u.Timeout = int(pollTimeout / time.Second)
 	updates := bot.GetUpdatesChan(u)
 
-	for update := range updates {
+	polling.Store(true)
+	defer polling.Store(false)
+
+	for {
+		var update tgbotapi.Update
+		select {
+		case <-ctx.Done():
+			bot.StopReceivingUpdates()
+			logger.Log.Info("Telegram long polling stopped")
+			return
+		case upd, ok := <-updates:
+			if !ok {
+				return
+			}
+			update = upd
+		}
+
 		if update.Message != nil {
 			if err := handleMessage(bot, update.Message, store); err != nil {
 				logger.Log.Errorf("failed to handle message: %v", err)
@@ -28,5 +56,3 @@ func StartBotWithBot(bot *tgbotapi.BotAPI, store db.UserStatesStore) {
 		}
 	}
 }
-
-
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/usecase"
 	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/db"
 	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/redisqueue"
 	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
@@ -18,13 +19,27 @@ func handleMessage(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, store db.UserSta
 	chatID := msg.Chat.ID
 	text := msg.Text
 
+	if strings.HasPrefix(text, "/") {
+		clearPending(chatID)
+	}
+
 	if text == "/start" || text == "⚙ Изменить параметры" {
 		_ = redisqueue.StopAnalysis(store, chatID)
 		logger.Log.Infof("User %d reset parameters", chatID)
 
+		// расписание и фильтры переживают сброс параметров
+		var (
+			sched   domain.Schedule
+			filters domain.Filters
+		)
+		if old, _ := store.Get(chatID); old != nil {
+			sched, filters = old.Schedule, old.Filters
+		}
 		store.Delete(chatID)
 		store.Set(chatID, &domain.UserState{
-			Step: "waiting_for_input",
+			Step:     "waiting_for_input",
+			Schedule: sched,
+			Filters:  filters,
 		})
 
 		msg := tgbotapi.NewMessage(chatID, "Введите минимальную разницу и максимальную сумму через пробел. Например: 0.1 1000")
@@ -36,6 +51,26 @@ func handleMessage(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, store db.UserSta
 		return nil
 	}
 
+	if text == "/fees" {
+		if _, err := bot.Send(tgbotapi.NewMessage(chatID, usecase.Fees().String())); err != nil {
+			logger.Log.Errorf("failed to send message: %v", err)
+			return err
+		}
+		return nil
+	}
+
+	if text == "/history" {
+		return sendHistory(bot, chatID)
+	}
+
+	if text == "/settings" {
+		return sendSettings(bot, chatID, store)
+	}
+
+	if handled, err := handleScheduleCommand(bot, chatID, text, store); handled {
+		return err
+	}
+
 	if text == "▶️ Начать анализ" {
 		state, err := store.Get(chatID)
 		if err != nil || (state.Step != "ready_to_run" && state.Step != "not_active") {
@@ -88,6 +123,10 @@ func handleMessage(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, store db.UserSta
 		return nil
 	}
 
+	if kind, ok := takePending(chatID); ok {
+		return handleScheduleInput(bot, chatID, kind, text, store)
+	}
+
 	state, err := store.Get(chatID)
 	if err != nil {
 		bot.Send(tgbotapi.NewMessage(chatID, "Сначала введите /start"))
@@ -136,11 +175,10 @@ func handleCallback(bot *tgbotapi.BotAPI, cb *tgbotapi.CallbackQuery, store db.U
 
 	logger.Log.Infof("Received callback from user %d: %s", chatID, data)
 
-	switch data {
-	default:
-		logger.Log.Warnf("Unexpected callback data: %s", data)
-		_, _ = bot.Send(tgbotapi.NewMessage(chatID, "Неизвестная команда."))
+	if a, ok := parseSettingsData(data); ok {
+		return handleSettingsCallback(bot, cb, a, store)
 	}
 
-	return nil
+	logger.Log.Warnf("Unexpected callback data: %s", data)
+	return answerCallback(bot, cb, "Неизвестная команда.")
 }
\ No newline at end of file
@@ -0,0 +1,32 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// polling is true while StartBotWithBot is reading the updates channel.
+var polling atomic.Bool
+
+// CheckHealth fails when long polling is not running or the Bot API does not
+// answer getMe.
+func CheckHealth(ctx context.Context, bot *tgbotapi.BotAPI) error {
+	if !polling.Load() {
+		return errors.New("long polling is not running")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := bot.GetMe()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,54 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/db"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	journal db.OpportunityRepository
+	// historyLimit — сколько сигналов показывает /history
+	historyLimit = 10
+)
+
+func InitJournal(j db.OpportunityRepository) {
+	journal = j
+}
+
+// sendHistory shows the latest signals of the chat for the last 24 hours.
+func sendHistory(bot *tgbotapi.BotAPI, chatID int64) error {
+	if journal == nil {
+		_, _ = bot.Send(tgbotapi.NewMessage(chatID, "История недоступна."))
+		return nil
+	}
+
+	recs, err := journal.Query(db.OpportunityFilter{
+		ChatID: chatID,
+		From:   time.Now().Add(-24 * time.Hour),
+		Limit:  historyLimit,
+	})
+	if err != nil {
+		logger.Log.Errorf("failed to query history for %d: %v", chatID, err)
+		_, _ = bot.Send(tgbotapi.NewMessage(chatID, "Не удалось получить историю."))
+		return err
+	}
+	if len(recs) == 0 {
+		_, err := bot.Send(tgbotapi.NewMessage(chatID, "За последние 24 часа сигналов не было."))
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Последние сигналы (%d):\n", len(recs))
+	for _, r := range recs {
+		fmt.Fprintf(&b, "%s [%s] %s @ %.2f → %s @ %.2f, margin %.2f\n",
+			r.DetectedAt.Format("02.01 15:04:05"), r.Type, r.BuyExchange, r.BuyPrice, r.SellExchange, r.SellPrice, r.Margin)
+	}
+	_, err = bot.Send(tgbotapi.NewMessage(chatID, b.String()))
+	return err
+}
@@ -0,0 +1,110 @@
+package telegram
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// LiveNotifier keeps one message per active signal of a chat and edits it
+// while the signal lasts, instead of sending a new message every time.
+type LiveNotifier struct {
+	bot *tgbotapi.BotAPI
+
+	mu       sync.Mutex
+	messages map[liveKey]int // id сообщения активного сигнала
+}
+
+type liveKey struct {
+	chatID int64
+	key    string
+}
+
+func NewLiveNotifier(bot *tgbotapi.BotAPI) *LiveNotifier {
+	return &LiveNotifier{bot: bot, messages: make(map[liveKey]int)}
+}
+
+// Signal edits the message of the signal, or sends a new one when there is
+// none yet or Telegram refuses the edit (the message was deleted, is too
+// old). Other errors, e.g. 429, are returned for the caller to retry.
+func (n *LiveNotifier) Signal(chatID int64, key, text string) error {
+	k := liveKey{chatID, key}
+	n.mu.Lock()
+	id, ok := n.messages[k]
+	n.mu.Unlock()
+
+	if ok {
+		err := n.edit(chatID, id, text)
+		if !editRefused(err) {
+			return err
+		}
+		logger.Log.WithError(err).Infof("chat %d: cannot edit message %d, sending a new one", chatID, id)
+	}
+
+	msg, err := n.bot.Send(tgbotapi.NewMessage(chatID, text))
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	n.messages[k] = msg.MessageID
+	n.mu.Unlock()
+	return nil
+}
+
+// Closed turns the message of the signal into text and forgets it. Without
+// a message to edit, text is sent as a new one.
+func (n *LiveNotifier) Closed(chatID int64, key, text string) error {
+	k := liveKey{chatID, key}
+	n.mu.Lock()
+	id, ok := n.messages[k]
+	n.mu.Unlock()
+
+	var err error
+	if ok {
+		err = n.edit(chatID, id, text)
+		if editRefused(err) {
+			logger.Log.WithError(err).Infof("chat %d: cannot edit message %d, sending a new one", chatID, id)
+			ok = false
+		}
+	}
+	if !ok {
+		_, err = n.bot.Send(tgbotapi.NewMessage(chatID, text))
+	}
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	delete(n.messages, k)
+	n.mu.Unlock()
+	return nil
+}
+
+func (n *LiveNotifier) Forget(chatID int64, key string) error {
+	n.mu.Lock()
+	delete(n.messages, liveKey{chatID, key})
+	n.mu.Unlock()
+	return nil
+}
+
+func (n *LiveNotifier) edit(chatID int64, messageID int, text string) error {
+	_, err := n.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, text))
+	if isNotModified(err) {
+		return nil // тот же текст — сообщение и так актуально
+	}
+	return err
+}
+
+func isNotModified(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "message is not modified")
+}
+
+// editRefused reports whether Telegram rejected the edit itself (400), so
+// that a new message is the way out.
+func editRefused(err error) bool {
+	var tgErr *tgbotapi.Error
+	return errors.As(err, &tgErr) && tgErr.Code == 400
+}
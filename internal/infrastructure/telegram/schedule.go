@@ -0,0 +1,233 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/db"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/redisqueue"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Диалоги расписания: команда запоминает, какой ввод ждем от чата, а
+// следующее сообщение разбирается как этот ввод. Step в user_states не
+// трогаем, чтобы работающий анализ не останавливался.
+const (
+	inputInterval = "interval"
+	inputQuiet    = "quiet"
+	inputDays     = "days"
+	inputTimezone = "timezone"
+
+	maxTickInterval = time.Hour
+)
+
+var (
+	pendingMu sync.Mutex
+	pending   = make(map[int64]string)
+)
+
+var schedulePrompts = map[string]string{
+	"/interval": "Введите интервал анализа, например 30s или 5m. 0 — по умолчанию.",
+	"/quiet":    "Введите тихие часы, например 23:00-08:00. «нет» — без тихих часов.",
+	"/days":     "Введите дни, когда присылать сигналы: 1-5, пн-пт, сб,вс или «все».",
+	"/timezone": "Введите часовой пояс, например Europe/Moscow или UTC.",
+}
+
+var scheduleInputs = map[string]string{
+	"/interval": inputInterval,
+	"/quiet":    inputQuiet,
+	"/days":     inputDays,
+	"/timezone": inputTimezone,
+}
+
+func setPending(chatID int64, kind string) {
+	pendingMu.Lock()
+	pending[chatID] = kind
+	pendingMu.Unlock()
+}
+
+func takePending(chatID int64) (string, bool) {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	kind, ok := pending[chatID]
+	delete(pending, chatID)
+	return kind, ok
+}
+
+func clearPending(chatID int64) {
+	pendingMu.Lock()
+	delete(pending, chatID)
+	pendingMu.Unlock()
+}
+
+// handleScheduleCommand serves /schedule and the commands that start a
+// schedule dialog. It reports whether text was one of them.
+func handleScheduleCommand(bot *tgbotapi.BotAPI, chatID int64, text string, store db.UserStatesStore) (bool, error) {
+	if text == "/schedule" {
+		st, _ := store.Get(chatID)
+		var sched domain.Schedule
+		if st != nil {
+			sched = st.Schedule
+		}
+		reply := sched.String() + "\n\nИзменить: /interval, /quiet, /days, /timezone"
+		_, err := bot.Send(tgbotapi.NewMessage(chatID, reply))
+		return true, err
+	}
+
+	kind, ok := scheduleInputs[text]
+	if !ok {
+		return false, nil
+	}
+	if st, _ := store.Get(chatID); st == nil {
+		_, err := bot.Send(tgbotapi.NewMessage(chatID, "Сначала введите /start"))
+		return true, err
+	}
+	setPending(chatID, kind)
+	_, err := bot.Send(tgbotapi.NewMessage(chatID, schedulePrompts[text]))
+	return true, err
+}
+
+// handleScheduleInput applies the reply to a schedule dialog.
+func handleScheduleInput(bot *tgbotapi.BotAPI, chatID int64, kind, text string, store db.UserStatesStore) error {
+	st, err := store.Get(chatID)
+	if err != nil || st == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "Сначала введите /start"))
+		return err
+	}
+
+	text = strings.TrimSpace(text)
+	sched := st.Schedule
+	switch kind {
+	case inputInterval:
+		sched.TickInterval, err = parseTickInterval(text)
+	case inputQuiet:
+		sched.QuietFrom, sched.QuietTo, err = parseQuietHours(text)
+	case inputDays:
+		sched.Weekdays, err = parseWeekdays(text)
+	case inputTimezone:
+		if _, err = time.LoadLocation(text); err == nil && text != "Local" {
+			sched.Timezone = text
+		} else {
+			err = fmt.Errorf("неизвестный часовой пояс %q", text)
+		}
+	}
+	if err != nil {
+		setPending(chatID, kind) // ждем исправленный ввод
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Не понял: %v. Попробуйте ещё раз.", err)))
+		return nil
+	}
+
+	tickChanged := sched.TickInterval != st.Schedule.TickInterval
+	st.Schedule = sched
+	if err := store.Set(chatID, st); err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "Не удалось сохранить расписание."))
+		return err
+	}
+	if tickChanged {
+		redisqueue.SetChatTickInterval(chatID, sched.TickInterval)
+	}
+	logger.Log.Infof("User %d set schedule %s: %q", chatID, kind, text)
+
+	_, err = bot.Send(tgbotapi.NewMessage(chatID, "Расписание сохранено.\n"+sched.String()))
+	return err
+}
+
+func parseTickInterval(s string) (time.Duration, error) {
+	if s == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, errors.New("нужна длительность вроде 30s или 5m")
+	}
+	if d < time.Second || d > maxTickInterval {
+		return 0, fmt.Errorf("интервал должен быть от 1s до %v", maxTickInterval)
+	}
+	return d, nil
+}
+
+// parseQuietHours parses "HH:MM-HH:MM" into minutes from midnight; "нет"
+// turns quiet hours off.
+func parseQuietHours(s string) (from, to int, err error) {
+	switch strings.ToLower(s) {
+	case "нет", "off", "0":
+		return 0, 0, nil
+	}
+	a, b, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, errors.New("нужен интервал вида 23:00-08:00")
+	}
+	if from, err = parseClock(a); err != nil {
+		return 0, 0, err
+	}
+	if to, err = parseClock(b); err != nil {
+		return 0, 0, err
+	}
+	if from == to {
+		return 0, 0, errors.New("начало и конец совпадают")
+	}
+	return from, to, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("неверное время %q", strings.TrimSpace(s))
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseWeekdays parses a comma-separated list of days and ranges, by number
+// (1 — понедельник, 7 — воскресенье) or short name: "1-5", "пн-пт,вс".
+func parseWeekdays(s string) (uint8, error) {
+	switch strings.ToLower(s) {
+	case "все", "*", "all":
+		return 0, nil
+	}
+	var mask uint8
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		part = strings.TrimSpace(part)
+		a, b, isRange := strings.Cut(part, "-")
+		from, err := parseWeekday(a)
+		if err != nil {
+			return 0, err
+		}
+		to := from
+		if isRange {
+			if to, err = parseWeekday(b); err != nil {
+				return 0, err
+			}
+		}
+		// диапазон может переходить через воскресенье: пт-пн
+		for d := from; ; d = (d + 1) % 7 {
+			mask |= 1 << uint(d)
+			if d == to {
+				break
+			}
+		}
+	}
+	return mask, nil
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || n > 7 {
+			return 0, fmt.Errorf("день %d вне 1..7", n)
+		}
+		return time.Weekday(n % 7), nil
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if s == domain.WeekdayName(d) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("неизвестный день %q", s)
+}
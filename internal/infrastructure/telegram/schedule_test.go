@@ -0,0 +1,73 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWeekdays(t *testing.T) {
+	mask := func(days ...time.Weekday) uint8 {
+		var m uint8
+		for _, d := range days {
+			m |= 1 << uint(d)
+		}
+		return m
+	}
+
+	tests := []struct {
+		in      string
+		want    uint8
+		wantErr bool
+	}{
+		{in: "все", want: 0},
+		{in: "1-5", want: mask(time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)},
+		{in: "пн-пт", want: mask(time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)},
+		{in: "сб,вс", want: mask(time.Saturday, time.Sunday)},
+		{in: "7", want: mask(time.Sunday)},
+		{in: "пт-пн", want: mask(time.Friday, time.Saturday, time.Sunday, time.Monday)},
+		{in: "6-1", want: mask(time.Saturday, time.Sunday, time.Monday)},
+		{in: "вс-вс", want: mask(time.Sunday)},
+		{in: "ПН-ср, пт", want: mask(time.Monday, time.Tuesday, time.Wednesday, time.Friday)},
+		{in: "0", wantErr: true},
+		{in: "8", wantErr: true},
+		{in: "пн-xx", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseWeekdays(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWeekdays(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseWeekdays(%q) = %07b, want %07b", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuietHours(t *testing.T) {
+	tests := []struct {
+		in       string
+		from, to int
+		wantErr  bool
+	}{
+		{in: "нет"},
+		{in: "23:00-08:00", from: 23 * 60, to: 8 * 60},
+		{in: "00:30-06:15", from: 30, to: 6*60 + 15},
+		{in: "22:00 - 23:59", from: 22 * 60, to: 23*60 + 59},
+		{in: "08:00-08:00", wantErr: true},
+		{in: "23:00", wantErr: true},
+		{in: "25:00-08:00", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			from, to, err := parseQuietHours(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseQuietHours(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			}
+			if from != tt.from || to != tt.to {
+				t.Errorf("parseQuietHours(%q) = %d-%d, want %d-%d", tt.in, from, to, tt.from, tt.to)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package telegram
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shyyw1e/arbitrage-sync/internal/core/domain"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/db"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/parser"
+	"github.com/Shyyw1e/arbitrage-sync/internal/infrastructure/redisqueue"
+	"github.com/Shyyw1e/arbitrage-sync/pkg/logger"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Меню /settings: callback data вида "set:<поле>:<аргумент>", например
+// "set:min:+", "set:src:rapira", "set:sig:depth". Telegram ограничивает
+// data 64 байтами, имена площадок и типов сигналов в них укладываются.
+const (
+	settingsPrefix = "set:"
+
+	fieldMinDiff = "min"
+	fieldMaxSum  = "sum"
+	fieldTick    = "tick"
+	fieldSource  = "src"
+	fieldSignal  = "sig"
+	fieldDone    = "done"
+	fieldNop     = "nop" // подпись между ➖ и ➕
+)
+
+// Значения, по которым шагают кнопки ➖ и ➕.
+var (
+	minDiffSteps = []float64{0, 0.05, 0.1, 0.2, 0.3, 0.5, 1, 2, 5}
+	maxSumSteps  = []float64{1000, 5000, 10000, 50000, 100000, 300000, 500000, 1000000}
+	tickSteps    = []time.Duration{0, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+		time.Minute, 5 * time.Minute, 15 * time.Minute, maxTickInterval}
+)
+
+// settingsAction is the parsed callback data of a settings menu button.
+type settingsAction struct {
+	field string
+	arg   string
+}
+
+func settingsData(field, arg string) string {
+	if arg == "" {
+		return settingsPrefix + field
+	}
+	return settingsPrefix + field + ":" + arg
+}
+
+func parseSettingsData(data string) (settingsAction, bool) {
+	rest, ok := strings.CutPrefix(data, settingsPrefix)
+	if !ok {
+		return settingsAction{}, false
+	}
+	field, arg, _ := strings.Cut(rest, ":")
+	return settingsAction{field: field, arg: arg}, true
+}
+
+// step moves cur to the next value of steps up or down; a value between
+// steps goes to the nearest one in that direction.
+func step[T cmp.Ordered](steps []T, cur T, up bool) T {
+	if up {
+		for _, v := range steps {
+			if v > cur {
+				return v
+			}
+		}
+		return steps[len(steps)-1]
+	}
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i] < cur {
+			return steps[i]
+		}
+	}
+	return steps[0]
+}
+
+func tickLabel(d time.Duration) string {
+	if d == 0 {
+		return "по умолчанию"
+	}
+	return d.String()
+}
+
+func formatSum(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func settingsText(st *domain.UserState) string {
+	return fmt.Sprintf("⚙ Настройки\nМинимальная разница: %.2f\nМаксимальная сумма: %s\nИнтервал анализа: %s\n\nНажмите на площадку или тип сигнала, чтобы включить или выключить его.",
+		st.MinDiff, formatSum(st.MaxSum), tickLabel(st.Schedule.TickInterval))
+}
+
+func settingsKeyboard(st *domain.UserState) tgbotapi.InlineKeyboardMarkup {
+	stepper := func(field, label string) []tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➖", settingsData(field, "-")),
+			tgbotapi.NewInlineKeyboardButtonData(label, settingsData(fieldNop, "")),
+			tgbotapi.NewInlineKeyboardButtonData("➕", settingsData(field, "+")),
+		)
+	}
+	toggle := func(on bool, label, data string) tgbotapi.InlineKeyboardButton {
+		mark := "❌ "
+		if on {
+			mark = "✅ "
+		}
+		return tgbotapi.NewInlineKeyboardButtonData(mark+label, data)
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		stepper(fieldMinDiff, fmt.Sprintf("Мин. разница: %.2f", st.MinDiff)),
+		stepper(fieldMaxSum, "Макс. сумма: "+formatSum(st.MaxSum)),
+		stepper(fieldTick, "Интервал: "+tickLabel(st.Schedule.TickInterval)),
+	}
+
+	// только площадки, включенные в конфиге: остальные не анализируются
+	var row []tgbotapi.InlineKeyboardButton
+	for _, src := range parser.DefaultRegistry.Enabled() {
+		name := src.Name()
+		row = append(row, toggle(st.Filters.SourceEnabled(name), string(name), settingsData(fieldSource, string(name))))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+		row = nil
+	}
+
+	for _, t := range domain.SignalTypes {
+		row = append(row, toggle(st.Filters.SignalEnabled(t), domain.SignalTypeName(t), settingsData(fieldSignal, string(t))))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Готово", settingsData(fieldDone, "")),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// sendSettings serves /settings: a message with the settings menu.
+func sendSettings(bot *tgbotapi.BotAPI, chatID int64, store db.UserStatesStore) error {
+	st, _ := store.Get(chatID)
+	if st == nil {
+		_, err := bot.Send(tgbotapi.NewMessage(chatID, "Сначала введите /start"))
+		return err
+	}
+	msg := tgbotapi.NewMessage(chatID, settingsText(st))
+	msg.ReplyMarkup = settingsKeyboard(st)
+	_, err := bot.Send(msg)
+	return err
+}
+
+// handleSettingsCallback applies a settings menu button, pushes the change
+// to the running analysis, answers the callback and redraws the menu in
+// the same message.
+func handleSettingsCallback(bot *tgbotapi.BotAPI, cb *tgbotapi.CallbackQuery, a settingsAction, store db.UserStatesStore) error {
+	chatID := cb.Message.Chat.ID
+	messageID := cb.Message.MessageID
+
+	if a.field == fieldNop {
+		return answerCallback(bot, cb, "")
+	}
+
+	st, err := store.Get(chatID)
+	if err != nil || st == nil {
+		answerCallback(bot, cb, "Сначала введите /start")
+		return err
+	}
+
+	if a.field == fieldDone {
+		edit := tgbotapi.NewEditMessageText(chatID, messageID, settingsSummary(st))
+		if _, err := bot.Send(edit); err != nil && !isNotModified(err) {
+			logger.Log.WithError(err).Warnf("chat %d: failed to close settings menu", chatID)
+		}
+		return answerCallback(bot, cb, "")
+	}
+
+	up := a.arg == "+"
+	paramsChanged, tickChanged := false, false
+	switch a.field {
+	case fieldMinDiff:
+		st.MinDiff = step(minDiffSteps, st.MinDiff, up)
+		paramsChanged = true
+	case fieldMaxSum:
+		st.MaxSum = step(maxSumSteps, st.MaxSum, up)
+		paramsChanged = true
+	case fieldTick:
+		st.Schedule.TickInterval = step(tickSteps, st.Schedule.TickInterval, up)
+		tickChanged = true
+	case fieldSource:
+		st.Filters.ToggleSource(domain.Source(a.arg))
+	case fieldSignal:
+		st.Filters.ToggleSignal(domain.SignalType(a.arg))
+	default:
+		logger.Log.Warnf("Unexpected settings callback data: %s", cb.Data)
+		return answerCallback(bot, cb, "Неизвестная команда.")
+	}
+
+	if err := store.Set(chatID, st); err != nil {
+		answerCallback(bot, cb, "Не удалось сохранить настройки.")
+		return err
+	}
+	logger.Log.Infof("User %d changed setting %s %s", chatID, a.field, a.arg)
+
+	// фильтры воркер читает из user_states на каждом тике, остальное — пушим
+	if paramsChanged && st.Step == "ready_to_run" {
+		if err := redisqueue.UpdateAnalysisParams(chatID, st.MinDiff, st.MaxSum); err != nil {
+			logger.Log.WithError(err).Warnf("chat %d: failed to update analysis params", chatID)
+		}
+	}
+	if tickChanged {
+		redisqueue.SetChatTickInterval(chatID, st.Schedule.TickInterval)
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, settingsText(st), settingsKeyboard(st))
+	if _, err := bot.Send(edit); err != nil && !isNotModified(err) {
+		logger.Log.WithError(err).Warnf("chat %d: failed to update settings menu", chatID)
+	}
+	return answerCallback(bot, cb, "Сохранено")
+}
+
+func settingsSummary(st *domain.UserState) string {
+	var off []string
+	for _, s := range st.Filters.DisabledSources {
+		off = append(off, string(s))
+	}
+	for _, t := range st.Filters.DisabledSignals {
+		off = append(off, domain.SignalTypeName(t))
+	}
+	text := fmt.Sprintf("⚙ Настройки сохранены\nМинимальная разница: %.2f\nМаксимальная сумма: %s\nИнтервал анализа: %s",
+		st.MinDiff, formatSum(st.MaxSum), tickLabel(st.Schedule.TickInterval))
+	if len(off) > 0 {
+		text += "\nВыключены: " + strings.Join(off, ", ")
+	}
+	return text
+}
+
+// answerCallback stops the button's loading indicator, showing text if any.
+func answerCallback(bot *tgbotapi.BotAPI, cb *tgbotapi.CallbackQuery, text string) error {
+	_, err := bot.Request(tgbotapi.NewCallback(cb.ID, text))
+	if err != nil {
+		logger.Log.WithError(err).Warnf("failed to answer callback %s", cb.ID)
+	}
+	return err
+}
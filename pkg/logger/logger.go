@@ -8,30 +8,39 @@ var Log *logrus.Logger
 
 func InitLog(loglevel string) *logrus.Logger {
 	logger := logrus.New()
+	logger.Level = parseLevel(loglevel)
+	logger.Formatter = &logrus.TextFormatter{
+		FullTimestamp: true,
+		DisableColors: false,
+		ForceColors: true,
+	}
+	Log = logger
+
+	return logger
+}
+
+// SetLevel changes the level of the running logger.
+func SetLevel(loglevel string) {
+	Log.SetLevel(parseLevel(loglevel))
+}
+
+func parseLevel(loglevel string) logrus.Level {
 	switch {
 	case loglevel == "debug":
-		logger.Level = logrus.DebugLevel
+		return logrus.DebugLevel
 	case loglevel == "info":
-		logger.Level = logrus.InfoLevel
+		return logrus.InfoLevel
 	case loglevel == "error":
-		logger.Level = logrus.ErrorLevel
+		return logrus.ErrorLevel
 	case loglevel == "fatal":
-		logger.Level = logrus.FatalLevel
+		return logrus.FatalLevel
 	case loglevel == "warn":
-		logger.Level = logrus.WarnLevel
+		return logrus.WarnLevel
 	case loglevel == "panic":
-		logger.Level = logrus.PanicLevel
+		return logrus.PanicLevel
 	case loglevel == "trace":
-		logger.Level = logrus.TraceLevel
+		return logrus.TraceLevel
 	default:
-		logger.Level = logrus.InfoLevel
-	}
-	logger.Formatter = &logrus.TextFormatter{
-		FullTimestamp: true,
-		DisableColors: false,
-		ForceColors: true,
+		return logrus.InfoLevel
 	}
-	Log = logger
-
-	return logger
 }